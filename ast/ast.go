@@ -178,6 +178,7 @@ type FunctionLiteral struct {
 	Token      token.Token // should be 'ft'
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Generator  bool // a yield appears in Body outside nested functions, theirs yield to it too
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -199,9 +200,36 @@ func (fl *FunctionLiteral) String() string {
 	return output.String()
 }
 
+type SpawnExpression struct {
+	Token token.Token // should be `spawn`
+	Call  *CallExpression
+}
+
+func (se *SpawnExpression) expressionNode()      {}
+func (se *SpawnExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpawnExpression) String() string {
+	return se.TokenLiteral() + " " + se.Call.String()
+}
+
+type YieldExpression struct {
+	Token token.Token // should be `yield`
+	Value Expression  // nil yields null
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) String() string {
+	if ye.Value == nil {
+		return ye.TokenLiteral()
+	}
+
+	return ye.TokenLiteral() + " " + ye.Value.String()
+}
+
 type HashLiteral struct {
 	Token token.Token // should be `{`
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -210,8 +238,8 @@ func (hl *HashLiteral) String() string {
 	var output bytes.Buffer
 
 	var pairs []string
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	output.WriteString("{")
@@ -263,6 +291,33 @@ func (ie *IndexExpression) String() string {
 	return output.String()
 }
 
+type SliceExpression struct {
+	Token token.Token // should be `[`
+	Left  Expression
+	Start Expression // nil means from the beginning
+	End   Expression // nil means to the end
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("(")
+	output.WriteString(se.Left.String())
+	output.WriteString("[")
+	if se.Start != nil {
+		output.WriteString(se.Start.String())
+	}
+	output.WriteString(":")
+	if se.End != nil {
+		output.WriteString(se.End.String())
+	}
+	output.WriteString("])")
+
+	return output.String()
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -2,6 +2,13 @@ package evaluator
 
 import (
 	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/Aergiaaa/simplescript/object"
 )
@@ -9,7 +16,7 @@ import (
 var builtins = map[string]*object.Builtin{
 	// return len of a variable
 	"len": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got=%d, want=1", len(args))
 			}
@@ -17,7 +24,7 @@ var builtins = map[string]*object.Builtin{
 			switch arg := args[0].(type) {
 			case *object.String:
 				return &object.Integer{
-					Value: int64(len(arg.Value)),
+					Value: int64(utf8.RuneCountInString(arg.Value)),
 				}
 			case *object.Array:
 				return &object.Integer{
@@ -31,13 +38,13 @@ var builtins = map[string]*object.Builtin{
 
 	// return the first elements of array
 	"head": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments.")
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARR_OBJ {
-				return newError("argument to `first` must be ARRAY, got=%s", args[0].Type())
+				return newError("argument to `head` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -51,13 +58,13 @@ var builtins = map[string]*object.Builtin{
 
 	// return last piece of array
 	"tail": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments.")
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARR_OBJ {
-				return newError("argument to `first` must be ARRAY, got=%s", args[0].Type())
+				return newError("argument to `tail` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -71,13 +78,13 @@ var builtins = map[string]*object.Builtin{
 
 	// returning its array without the first val
 	"killHead": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments.")
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
 			}
 
 			if args[0].Type() != object.ARR_OBJ {
-				return newError("argument to `first` must be ARRAY, got=%s", args[0].Type())
+				return newError("argument to `killHead` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -97,13 +104,13 @@ var builtins = map[string]*object.Builtin{
 
 	// return array with added piece at the end
 	"push": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments.")
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
 			}
 
 			if args[0].Type() != object.ARR_OBJ {
-				return newError("argument to `first` must be ARRAY, got=%s", args[0].Type())
+				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
 			}
 
 			arr := args[0].(*object.Array)
@@ -119,13 +126,191 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 
+	// lexicographic compare of two strings giving -1, 0 or 1,
+	// pass true as third argument to ignore case
+	"compare": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments, got=%d, want=2 or 3", len(args))
+			}
+
+			a, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `compare` must be STRING, got %s", args[0].Type())
+			}
+
+			b, ok := args[1].(*object.String)
+			if !ok {
+				return newError("argument to `compare` must be STRING, got %s", args[1].Type())
+			}
+
+			if len(args) == 3 && isTruthy(args[2]) {
+				return &object.Integer{Value: int64(compareFold(a.Value, b.Value))}
+			}
+
+			return &object.Integer{Value: int64(strings.Compare(a.Value, b.Value))}
+		},
+	},
+
+	// convert a number or numeric string to INTEGER (or BIGINT when too big),
+	// floats are truncated
+	"int": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					return newError("cannot convert %s to integer", arg.Inspect())
+				}
+				i, _ := big.NewFloat(arg.Value).Int(nil)
+				return object.IntegerFromBig(i)
+			case *object.String:
+				i, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+				if !ok {
+					return newError("could not parse %q as integer", arg.Value)
+				}
+				return object.IntegerFromBig(i)
+			default:
+				return newError("argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// convert a number or numeric string to FLOAT
+	"float": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt, *object.Float:
+				return &object.Float{Value: toFloat(arg)}
+			case *object.String:
+				f, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("could not parse %q as float", arg.Value)
+				}
+				return &object.Float{Value: f}
+			default:
+				return newError("argument to `float` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// string form of any value, same as puts prints it
+	"str": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+
+	// STRING to ARRAY of byte values, or such ARRAY back to STRING
+	"bytes": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				elems := make([]object.Object, len(arg.Value))
+				for i := 0; i < len(arg.Value); i++ {
+					elems[i] = &object.Integer{Value: int64(arg.Value[i])}
+				}
+				return &object.Array{Elems: elems}
+			case *object.Array:
+				buf := make([]byte, len(arg.Elems))
+				for i, elem := range arg.Elems {
+					b, ok := elem.(*object.Integer)
+					if !ok || b.Value < 0 || b.Value > 255 {
+						return newError("argument to `bytes` must hold integers 0-255, got %s", elem.Inspect())
+					}
+					buf[i] = byte(b.Value)
+				}
+				return &object.String{Value: string(buf)}
+			default:
+				return newError("argument to `bytes` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// STRING to ARRAY of code points, or such ARRAY back to STRING
+	"runes": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				var elems []object.Object
+				for _, r := range arg.Value {
+					elems = append(elems, &object.Integer{Value: int64(r)})
+				}
+				return &object.Array{Elems: elems}
+			case *object.Array:
+				runes := make([]rune, len(arg.Elems))
+				for i, elem := range arg.Elems {
+					r, ok := elem.(*object.Integer)
+					if !ok || r.Value < 0 || r.Value > unicode.MaxRune {
+						return newError("argument to `runes` must hold code points, got %s", elem.Inspect())
+					}
+					runes[i] = rune(r.Value)
+				}
+				return &object.String{Value: string(runes)}
+			default:
+				return newError("argument to `runes` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+
 	// print
 	"puts": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				fmt.Fprintln(ctx.Stdout, arg.Inspect())
 			}
 			return NULL
 		},
 	},
 }
+
+// compareFold compares rune by rune after simple case folding
+func compareFold(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	for i := 0; i < len(ar) && i < len(br); i++ {
+		ca, cb := unicode.ToLower(ar[i]), unicode.ToLower(br[i])
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(ar) < len(br):
+		return -1
+	case len(ar) > len(br):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// register adds a set of builtins defined in another file, it runs from
+// init because those builtins call back into the evaluator
+func register(set map[string]*object.Builtin) {
+	maps.Copy(builtins, set)
+}
@@ -0,0 +1,409 @@
+package evaluator
+
+import (
+	"slices"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(collectionBuiltins)
+}
+
+// elemsOf collects every value of an iterable argument, arrays are used as is
+func elemsOf(ctx *object.Context, name string, obj object.Object) ([]object.Object, object.Object) {
+	if arr, ok := obj.(*object.Array); ok {
+		return arr.Elems, nil
+	}
+
+	it, ok := iterate(ctx, obj)
+	if !ok {
+		return nil, newError("argument to `%s` must be iterable, got %s", name, obj.Type())
+	}
+
+	res := collect(it)
+	if isError(res) {
+		return nil, res
+	}
+
+	return res.(*object.Array).Elems, nil
+}
+
+// each value of an iterable argument with f applied to it, stopping at the
+// first error. fn returning false stops early
+func eachApplied(ctx *object.Context, name string, src, f object.Object, fn func(val, res object.Object) bool) object.Object {
+	it, ok := iterate(ctx, src)
+	if !ok {
+		return newError("argument to `%s` must be iterable, got %s", name, src.Type())
+	}
+
+	for {
+		val, ok := it.Next()
+		if !ok {
+			return nil
+		}
+
+		if isError(val) {
+			return val
+		}
+
+		res := val
+		if f != nil {
+			res = applyFunc(ctx, f, []object.Object{val})
+			if isError(res) {
+				return res
+			}
+		}
+
+		if !fn(val, res) {
+			return nil
+		}
+	}
+}
+
+// compareObjects orders a and b with `<`, so numbers and strings sort
+func compareObjects(a, b object.Object) (int, object.Object) {
+	less := evalInfixExpr("<", a, b)
+	if isError(less) {
+		return 0, less
+	}
+
+	if isTruthy(less) {
+		return -1, nil
+	}
+
+	if isTruthy(evalInfixExpr("<", b, a)) {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// flatten appends elems to out with nested arrays spliced in depth levels
+// deep, seen holds the arrays being flattened so a cyclic one is an error
+func flatten(elems []object.Object, depth int64, out []object.Object, seen map[*object.Array]bool) ([]object.Object, *object.Error) {
+	for _, elem := range elems {
+		arr, ok := elem.(*object.Array)
+		if !ok || depth == 0 {
+			out = append(out, elem)
+			continue
+		}
+
+		if seen[arr] {
+			return nil, newError("cannot flatten cyclic ARRAY")
+		}
+
+		seen[arr] = true
+		var err *object.Error
+		out, err = flatten(arr.Elems, depth-1, out, seen)
+		delete(seen, arr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+var collectionBuiltins = map[string]*object.Builtin{
+	// reduce(iterable, f, init?) folds the values with f(acc, val), without
+	// init the first value starts the fold
+	"reduce": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments, got=%d, want=2 or 3", len(args))
+			}
+
+			it, ok := iterate(ctx, args[0])
+			if !ok {
+				return newError("argument to `reduce` must be iterable, got %s", args[0].Type())
+			}
+
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else {
+				acc, ok = it.Next()
+				if !ok {
+					return newError("reduce of empty iterable with no initial value")
+				}
+			}
+
+			for {
+				if isError(acc) {
+					return acc
+				}
+
+				val, ok := it.Next()
+				if !ok {
+					return acc
+				}
+
+				if isError(val) {
+					return val
+				}
+
+				acc = applyFunc(ctx, args[1], []object.Object{acc, val})
+			}
+		},
+	},
+
+	// call f with every value for its side effects
+	"each": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			if err := eachApplied(ctx, "each", args[0], args[1], func(val, res object.Object) bool {
+				return true
+			}); err != nil {
+				return err
+			}
+
+			return NULL
+		},
+	},
+
+	// first value f returns a truthy value for, null when there is none
+	"find": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			var found object.Object = NULL
+			if err := eachApplied(ctx, "find", args[0], args[1], func(val, res object.Object) bool {
+				if isTruthy(res) {
+					found = val
+					return false
+				}
+				return true
+			}); err != nil {
+				return err
+			}
+
+			return found
+		},
+	},
+
+	// any(iterable, f?) reports whether some value, or f of it, is truthy
+	"any": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			var f object.Object
+			if len(args) == 2 {
+				f = args[1]
+			}
+
+			found := false
+			if err := eachApplied(ctx, "any", args[0], f, func(val, res object.Object) bool {
+				found = isTruthy(res)
+				return !found
+			}); err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(found)
+		},
+	},
+
+	// all(iterable, f?) reports whether every value, or f of it, is truthy
+	"all": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			var f object.Object
+			if len(args) == 2 {
+				f = args[1]
+			}
+
+			all := true
+			if err := eachApplied(ctx, "all", args[0], f, func(val, res object.Object) bool {
+				all = isTruthy(res)
+				return all
+			}); err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(all)
+		},
+	},
+
+	// sorted copy of the values, sort(iterable, cmp) orders with cmp(a, b)
+	// returning a negative, zero or positive INTEGER. the sort is stable
+	"sort": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			elems, err := elemsOf(ctx, "sort", args[0])
+			if err != nil {
+				return err
+			}
+
+			cmp := compareObjects
+			if len(args) == 2 {
+				cmp = func(a, b object.Object) (int, object.Object) {
+					res := applyFunc(ctx, args[1], []object.Object{a, b})
+					if isError(res) {
+						return 0, res
+					}
+
+					n, ok := res.(*object.Integer)
+					if !ok {
+						return 0, newError("comparator of `sort` must return INTEGER, got %s", res.Type())
+					}
+
+					return int(max(min(n.Value, 1), -1)), nil
+				}
+			}
+
+			var sortErr object.Object
+			sorted := slices.Clone(elems)
+			slices.SortStableFunc(sorted, func(a, b object.Object) int {
+				if sortErr != nil {
+					return 0
+				}
+
+				c, err := cmp(a, b)
+				if err != nil {
+					sortErr = err
+				}
+				return c
+			})
+
+			if sortErr != nil {
+				return sortErr
+			}
+
+			return &object.Array{Elems: sorted}
+		},
+	},
+
+	// reversed copy of an array or string
+	"reverse": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				runes := []rune(str.Value)
+				slices.Reverse(runes)
+				return &object.String{Value: string(runes)}
+			}
+
+			elems, err := elemsOf(ctx, "reverse", args[0])
+			if err != nil {
+				return err
+			}
+
+			reversed := slices.Clone(elems)
+			slices.Reverse(reversed)
+			return &object.Array{Elems: reversed}
+		},
+	},
+
+	// flatten(array, depth?) splices nested arrays in, all the way down by default
+	"flatten": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `flatten` must be ARRAY, got %s", args[0].Type())
+			}
+
+			depth := int64(-1)
+			if len(args) == 2 {
+				n, ok := args[1].(*object.Integer)
+				if !ok || n.Value < 0 {
+					return newError("argument to `flatten` must be a non-negative INTEGER, got %s", args[1].Inspect())
+				}
+				depth = n.Value
+			}
+
+			elems, err := flatten(arr.Elems, depth, []object.Object{}, map[*object.Array]bool{arr: true})
+			if err != nil {
+				return err
+			}
+
+			return &object.Array{Elems: elems}
+		},
+	},
+
+	// the values without repeats, keeping the first of each
+	"uniq": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			elems, err := elemsOf(ctx, "uniq", args[0])
+			if err != nil {
+				return err
+			}
+
+			seen := object.InitHash()
+			uniq := []object.Object{}
+			for _, elem := range elems {
+				if _, ok := object.HashKeyOf(elem); ok {
+					if _, ok := seen.Get(elem); ok {
+						continue
+					}
+					seen.Set(elem, TRUE)
+				} else if slices.ContainsFunc(uniq, func(o object.Object) bool { return object.Equals(o, elem) }) {
+					continue
+				}
+
+				uniq = append(uniq, elem)
+			}
+
+			return &object.Array{Elems: uniq}
+		},
+	},
+
+	// hash from f(val) to the array of values giving that key, in order of appearance
+	"group_by": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			groups := object.InitHash()
+			var keyErr object.Object
+			if err := eachApplied(ctx, "group_by", args[0], args[1], func(val, key object.Object) bool {
+				if _, ok := object.HashKeyOf(key); !ok {
+					keyErr = newError("unusable as hash key: %s", key.Type())
+					return false
+				}
+
+				if pair, ok := groups.Get(key); ok {
+					group := pair.Val.(*object.Array)
+					group.Elems = append(group.Elems, val)
+					return true
+				}
+
+				groups.Set(key, &object.Array{Elems: []object.Object{val}})
+				return true
+			}); err != nil {
+				return err
+			}
+
+			if keyErr != nil {
+				return keyErr
+			}
+
+			return groups
+		},
+	},
+}
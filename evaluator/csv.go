@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(csvBuiltins)
+}
+
+// options reads an options hash with string keys, only known keys are accepted
+func options(name string, arg object.Object, known ...string) (map[string]object.Object, *object.Error) {
+	hash, ok := arg.(*object.Hash)
+	if !ok {
+		return nil, newError("argument to `%s` must be HASH, got %s", name, arg.Type())
+	}
+
+	opts := make(map[string]object.Object, hash.Len())
+	for _, pair := range hash.Items() {
+		key, ok := pair.Key.(*object.String)
+		if !ok || !slices.Contains(known, key.Value) {
+			return nil, newError("unknown option for `%s`: %s", name, pair.Key.Inspect())
+		}
+		opts[key.Value] = pair.Val
+	}
+
+	return opts, nil
+}
+
+// separatorOpt is the "separator" option, a single rune that csv can use,
+// comma by default
+func separatorOpt(name string, opts map[string]object.Object) (rune, *object.Error) {
+	sep, ok := opts["separator"]
+	if !ok {
+		return ',', nil
+	}
+
+	str, err := stringArg(name, sep)
+	if err != nil {
+		return 0, err
+	}
+
+	r, size := utf8.DecodeRuneInString(str)
+	if size == 0 || size != len(str) {
+		return 0, newError("separator of `%s` must be a single character, got %q", name, str)
+	}
+
+	if r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, newError("separator of `%s` must not be a quote or line break, got %q", name, str)
+	}
+
+	return r, nil
+}
+
+var csvBuiltins = map[string]*object.Builtin{
+	// csv_parse(str, opts?) is an array of rows, each an array of strings.
+	// options: "header" true turns the rows after the first into hashes
+	// keyed by the first, "separator" replaces the comma
+	"csv_parse": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("csv_parse", args[0])
+			if err != nil {
+				return err
+			}
+
+			opts := map[string]object.Object{}
+			if len(args) == 2 {
+				if opts, err = options("csv_parse", args[1], "header", "separator"); err != nil {
+					return err
+				}
+			}
+
+			r := csv.NewReader(strings.NewReader(str))
+			if r.Comma, err = separatorOpt("csv_parse", opts); err != nil {
+				return err
+			}
+
+			records, readErr := r.ReadAll()
+			if readErr != nil {
+				return newError("csv_parse: %s", readErr)
+			}
+
+			rows := make([]object.Object, len(records))
+			for i, record := range records {
+				rows[i] = stringsToArray(record)
+			}
+
+			header, ok := opts["header"]
+			if !ok || !isTruthy(header) || len(rows) == 0 {
+				return &object.Array{Elems: rows}
+			}
+
+			keys := rows[0].(*object.Array).Elems
+			for i, row := range rows[1:] {
+				hash := object.InitHash()
+				for j, val := range row.(*object.Array).Elems {
+					hash.Set(keys[j], val)
+				}
+				rows[i+1] = hash
+			}
+
+			return &object.Array{Elems: rows[1:]}
+		},
+	},
+
+	// csv_format(rows, opts?) writes rows of arrays, or of hashes under a
+	// header made of the keys of the first hash. options: "separator", and
+	// "header" false to leave out the header of hashes
+	"csv_format": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			rows, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `csv_format` must be ARRAY, got %s", args[0].Type())
+			}
+
+			opts := map[string]object.Object{}
+			if len(args) == 2 {
+				var err *object.Error
+				if opts, err = options("csv_format", args[1], "header", "separator"); err != nil {
+					return err
+				}
+			}
+
+			var buf bytes.Buffer
+			w := csv.NewWriter(&buf)
+
+			var err *object.Error
+			if w.Comma, err = separatorOpt("csv_format", opts); err != nil {
+				return err
+			}
+
+			var keys []object.Object
+			for _, row := range rows.Elems {
+				var record []string
+
+				switch row := row.(type) {
+				case *object.Array:
+					for _, val := range row.Elems {
+						record = append(record, textOf(val))
+					}
+				case *object.Hash:
+					if keys == nil {
+						for _, pair := range row.Items() {
+							keys = append(keys, pair.Key)
+						}
+
+						if header, ok := opts["header"]; !ok || isTruthy(header) {
+							names := make([]string, len(keys))
+							for i, key := range keys {
+								names[i] = textOf(key)
+							}
+							if err := w.Write(names); err != nil {
+								return newError("csv_format: %s", err)
+							}
+						}
+					}
+
+					for _, key := range keys {
+						var val object.Object = NULL
+						if pair, ok := row.Get(key); ok {
+							val = pair.Val
+						}
+						record = append(record, textOf(val))
+					}
+				default:
+					return newError("rows of `csv_format` must be ARRAY or HASH, got %s", row.Type())
+				}
+
+				if err := w.Write(record); err != nil {
+					return newError("csv_format: %s", err)
+				}
+			}
+
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return newError("csv_format: %s", err)
+			}
+
+			return &object.String{Value: buf.String()}
+		},
+	},
+}
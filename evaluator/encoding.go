@@ -0,0 +1,184 @@
+package evaluator
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/url"
+	"strings"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(encodingBuiltins)
+}
+
+// strings hold raw bytes, so decoders may return strings that are not
+// valid UTF-8. digests are hex encoded, hex_decode gives the raw bytes
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// digestFunc is a builtin giving the hex digest of its string argument
+func digestFunc(name string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs(name, args, 1)
+			if err != nil {
+				return err
+			}
+
+			h := hashes[name]()
+			h.Write([]byte(strs[0]))
+			return &object.String{Value: hex.EncodeToString(h.Sum(nil))}
+		},
+	}
+}
+
+// base64Encoding picks the url safe alphabet when the optional flag at args[i] is truthy
+func base64Encoding(args []object.Object, i int) *base64.Encoding {
+	if len(args) > i && isTruthy(args[i]) {
+		return base64.URLEncoding
+	}
+
+	return base64.StdEncoding
+}
+
+var encodingBuiltins = map[string]*object.Builtin{
+	// base64_encode(str, url?), url true uses the url safe alphabet
+	"base64_encode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("base64_encode", args[0])
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: base64Encoding(args, 1).EncodeToString([]byte(str))}
+		},
+	},
+
+	// base64_decode(str, url?)
+	"base64_decode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("base64_decode", args[0])
+			if err != nil {
+				return err
+			}
+
+			b, decodeErr := base64Encoding(args, 1).DecodeString(str)
+			if decodeErr != nil {
+				return newError("could not decode %q as base64: %s", str, decodeErr)
+			}
+
+			return &object.String{Value: string(b)}
+		},
+	},
+
+	"hex_encode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("hex_encode", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: hex.EncodeToString([]byte(strs[0]))}
+		},
+	},
+
+	"hex_decode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("hex_decode", args, 1)
+			if err != nil {
+				return err
+			}
+
+			b, decodeErr := hex.DecodeString(strs[0])
+			if decodeErr != nil {
+				return newError("could not decode %q as hex: %s", strs[0], decodeErr)
+			}
+
+			return &object.String{Value: string(b)}
+		},
+	},
+
+	// url_encode(str) escapes for a query string, url_encode(hash) builds a
+	// whole query string from its pairs in order
+	"url_encode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.String{Value: url.QueryEscape(arg.Value)}
+			case *object.Hash:
+				pairs := make([]string, 0, arg.Len())
+				for _, pair := range arg.Items() {
+					pairs = append(pairs, url.QueryEscape(pair.Key.Inspect())+"="+url.QueryEscape(pair.Val.Inspect()))
+				}
+				return &object.String{Value: strings.Join(pairs, "&")}
+			default:
+				return newError("argument to `url_encode` must be STRING or HASH, got %s", arg.Type())
+			}
+		},
+	},
+
+	"url_decode": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("url_decode", args, 1)
+			if err != nil {
+				return err
+			}
+
+			s, decodeErr := url.QueryUnescape(strs[0])
+			if decodeErr != nil {
+				return newError("could not decode %q as url: %s", strs[0], decodeErr)
+			}
+
+			return &object.String{Value: s}
+		},
+	},
+
+	"md5":    digestFunc("md5"),
+	"sha1":   digestFunc("sha1"),
+	"sha256": digestFunc("sha256"),
+	"sha512": digestFunc("sha512"),
+
+	// hmac(algo, key, msg) is the hex HMAC of msg, algo one of md5, sha1,
+	// sha256 or sha512
+	"hmac": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("hmac", args, 3)
+			if err != nil {
+				return err
+			}
+
+			newHash, ok := hashes[strs[0]]
+			if !ok {
+				return newError("unknown hash for `hmac`: %s", strs[0])
+			}
+
+			mac := hmac.New(newHash, []byte(strs[1]))
+			mac.Write([]byte(strs[2]))
+			return &object.String{Value: hex.EncodeToString(mac.Sum(nil))}
+		},
+	},
+}
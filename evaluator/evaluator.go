@@ -2,17 +2,24 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Aergiaaa/simplescript/ast"
 	"github.com/Aergiaaa/simplescript/object"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Bool{Value: true}
-	FALSE = &object.Bool{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
+// maxStringLen bounds the bytes a single operation may build into a string
+const maxStringLen = 1 << 30
+
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
@@ -30,7 +37,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			Parameters: node.Parameters,
 			Body:       node.Body,
 			Env:        env,
+			Generator:  node.Generator,
 		}
+	case *ast.YieldExpression:
+		return evalYieldExpr(node, env)
+	case *ast.SpawnExpression:
+		return evalSpawnExpr(node, env)
 	case *ast.ArrayLiteral:
 		elems := evalExprs(node.Elems, env)
 
@@ -53,6 +65,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 
 		return evalIndexExpr(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpr(node, env)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	case *ast.CallExpression:
@@ -66,7 +80,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
-		return applyFunc(f, args)
+		return applyFunc(env.Context(), f, args)
 	case *ast.IfExpression:
 		return evalIfExpr(node, env)
 	case *ast.ReturnStatement:
@@ -128,24 +142,47 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func applyFunc(fn object.Object, args []object.Object) object.Object {
+// Apply calls fn with args under ctx, it is how a host calls back into a script
+func Apply(ctx *object.Context, fn object.Object, args []object.Object) object.Object {
+	return applyFunc(ctx, fn, args)
+}
+
+func applyFunc(ctx *object.Context, fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extEnv := extFuncEnv(fn, args)
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments, got=%d, want=%d", len(args), len(fn.Parameters))
+		}
+
+		if err := ctx.Err(); err != nil {
+			return newError("execution cancelled: %s", err)
+		}
+
+		if fn.Generator {
+			return newGenerator(ctx, fn, args)
+		}
+
+		if !ctx.Enter() {
+			return newError("maximum call depth exceeded: %d", ctx.MaxDepth)
+		}
+		defer ctx.Leave()
+
+		extEnv := extFuncEnv(ctx, fn, args)
 		evaled := Eval(fn.Body, extEnv)
 
 		return unwrapReturnVal(evaled)
 
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return fn.Fn(ctx, args...)
 
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extFuncEnv(f *object.Function, args []object.Object) *object.Environment {
+func extFuncEnv(ctx *object.Context, f *object.Function, args []object.Object) *object.Environment {
 	env := object.InitEnclosedEnv(f.Env)
+	env.SetContext(ctx)
 
 	for i, param := range f.Parameters {
 		env.Set(param.Value, args[i])
@@ -183,24 +220,33 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
+	if builtin, ok := env.Context().Builtins[node.Value]; ok {
+		return builtin
+	}
+
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
 
+	if val, ok := constants[node.Value]; ok {
+		return val
+	}
+
 	return newError("identifier not found: %s", node.Value)
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.InitHash()
+
+	for _, keyNode := range node.Keys {
+		valNode := node.Pairs[keyNode]
 
-	for keyNode, valNode := range node.Pairs {
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := object.HashKeyOf(key); !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
@@ -209,17 +255,18 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return val
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Val: val}
+		hash.Set(key, val)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalIndexExpr(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARR_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpr(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpr(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpr(left, index)
 
@@ -231,12 +278,11 @@ func evalIndexExpr(left, index object.Object) object.Object {
 func evalHashIndexExpr(hash, index object.Object) object.Object {
 	hashObj := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
+	if _, ok := object.HashKeyOf(index); !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObj.Pairs[key.HashKey()]
+	pair, ok := hashObj.Get(index)
 	if !ok {
 		return NULL
 	}
@@ -261,6 +307,84 @@ func evalArrayIndexExpr(arr, index object.Object) object.Object {
 	return arrObj.Elems[i]
 }
 
+// evalStringIndexExpr index by rune, the result is a one rune STRING
+func evalStringIndexExpr(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	i := index.(*object.Integer).Value
+
+	if i < 0 {
+		return newError("index out of bound: %d", i)
+	}
+
+	if i >= int64(len(runes)) {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[i])}
+}
+
+// evalSliceExpr slice an ARRAY by element or a STRING by rune, bounds past
+// the end are clamped like an out of range index gives null
+func evalSliceExpr(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var length int
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elems)
+	case *object.String:
+		length = utf8.RuneCountInString(left.Value)
+	default:
+		return newError("slice operator is not supported: %s", left.Type())
+	}
+
+	start, errObj := evalSliceBound(node.Start, env, 0, length)
+	if errObj != nil {
+		return errObj
+	}
+
+	end, errObj := evalSliceBound(node.End, env, length, length)
+	if errObj != nil {
+		return errObj
+	}
+	end = max(start, end)
+
+	switch left := left.(type) {
+	case *object.Array:
+		elems := make([]object.Object, end-start)
+		copy(elems, left.Elems[start:end])
+		return &object.Array{Elems: elems}
+	default:
+		runes := []rune(left.(*object.String).Value)
+		return &object.String{Value: string(runes[start:end])}
+	}
+}
+
+func evalSliceBound(node ast.Expression, env *object.Environment, def, length int) (int, object.Object) {
+	if node == nil {
+		return def, nil
+	}
+
+	bound := Eval(node, env)
+	if isError(bound) {
+		return 0, bound
+	}
+
+	i, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, newError("slice bound must be INTEGER, got %s", bound.Type())
+	}
+
+	if i.Value < 0 {
+		return 0, newError("index out of bound: %d", i.Value)
+	}
+
+	return int(min(i.Value, int64(length))), nil
+}
+
 func evalIfExpr(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -310,12 +434,28 @@ func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) (re
 func evalInfixExpr(op string, left, right object.Object) object.Object {
 	l, r := left.Type(), right.Type()
 	switch {
+	case op == "in":
+		return evalInExpr(left, right)
 	case isSameObjType(l, r, object.INTEGER_OBJ):
 		return evalIntegInfixExpr(op, left, right)
+	case isInteger(l) && isInteger(r):
+		return evalBigInfixExpr(op, left, right)
+	case isNumber(l) && isNumber(r):
+		return evalFloatInfixExpr(op, left, right)
+	case op == "==":
+		return nativeBoolToBoolObj(object.Equals(left, right))
+	case op == "!=":
+		return nativeBoolToBoolObj(!object.Equals(left, right))
 	case isSameObjType(l, r, object.BOOL_OBJ):
 		return evalBoolInfixExpr(op, left, right)
 	case isSameObjType(l, r, object.STRING_OBJ):
 		return evalStringInfixExpr(op, left, right)
+	case op == "*" && l == object.STRING_OBJ && r == object.INTEGER_OBJ:
+		return evalStringRepeat(left, right)
+	case op == "*" && l == object.INTEGER_OBJ && r == object.STRING_OBJ:
+		return evalStringRepeat(right, left)
+	case isTime(l) || isTime(r):
+		return evalTimeInfixExpr(op, left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
 	default:
@@ -323,6 +463,36 @@ func evalInfixExpr(op string, left, right object.Object) object.Object {
 	}
 }
 
+func isInteger(t object.ObjectType) bool {
+	return t == object.INTEGER_OBJ || t == object.BIGINT_OBJ
+}
+
+func isNumber(t object.ObjectType) bool {
+	return isInteger(t) || t == object.FLOAT_OBJ
+}
+
+// toFloat widen any number, caller must check with isNumber first
+func toFloat(obj object.Object) float64 {
+	switch num := obj.(type) {
+	case *object.Integer:
+		return float64(num.Value)
+	case *object.BigInt:
+		f, _ := new(big.Float).SetInt(num.Value).Float64()
+		return f
+	default:
+		return obj.(*object.Float).Value
+	}
+}
+
+// toBig widen an INTEGER or BIGINT, caller must check with isInteger first
+func toBig(obj object.Object) *big.Int {
+	if i, ok := obj.(*object.Integer); ok {
+		return big.NewInt(i.Value)
+	}
+
+	return obj.(*object.BigInt).Value
+}
+
 func isSameObjType(left, right object.ObjectType, obj object.ObjectType) bool {
 	return left == obj && right == obj
 }
@@ -344,12 +514,30 @@ func evalIntegInfixExpr(op string, left, right object.Object) object.Object {
 
 	switch op {
 	case "+":
-		return &object.Integer{Value: lVal + rVal}
+		res := lVal + rVal
+		if (rVal > 0 && res < lVal) || (rVal < 0 && res > lVal) {
+			return evalBigInfixExpr(op, left, right)
+		}
+		return &object.Integer{Value: res}
 	case "-":
-		return &object.Integer{Value: lVal - rVal}
+		res := lVal - rVal
+		if (rVal < 0 && res < lVal) || (rVal > 0 && res > lVal) {
+			return evalBigInfixExpr(op, left, right)
+		}
+		return &object.Integer{Value: res}
 	case "*":
-		return &object.Integer{Value: lVal * rVal}
+		res := lVal * rVal
+		if lVal != 0 && (res/lVal != rVal || (lVal == -1 && rVal == math.MinInt64)) {
+			return evalBigInfixExpr(op, left, right)
+		}
+		return &object.Integer{Value: res}
 	case "/":
+		if rVal == 0 {
+			return newError("division by zero")
+		}
+		if lVal == math.MinInt64 && rVal == -1 {
+			return evalBigInfixExpr(op, left, right)
+		}
 		return &object.Integer{Value: lVal / rVal}
 	case "==":
 		return nativeBoolToBoolObj(lVal == rVal)
@@ -368,16 +556,132 @@ func evalIntegInfixExpr(op string, left, right object.Object) object.Object {
 	}
 }
 
-func evalStringInfixExpr(op string, left, right object.Object) object.Object {
-	if op != "+" {
+// evalBigInfixExpr is integer arithmetic on math/big, used once an INTEGER
+// operation overflow or a BIGINT is involved
+func evalBigInfixExpr(op string, left, right object.Object) object.Object {
+	lVal := toBig(left)
+	rVal := toBig(right)
+
+	switch op {
+	case "+":
+		return object.IntegerFromBig(new(big.Int).Add(lVal, rVal))
+	case "-":
+		return object.IntegerFromBig(new(big.Int).Sub(lVal, rVal))
+	case "*":
+		return object.IntegerFromBig(new(big.Int).Mul(lVal, rVal))
+	case "/":
+		if rVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return object.IntegerFromBig(new(big.Int).Quo(lVal, rVal))
+	case "==":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) == 0)
+	case "!=":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) != 0)
+	case ">=":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) >= 0)
+	case "<=":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) <= 0)
+	case ">":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) > 0)
+	case "<":
+		return nativeBoolToBoolObj(lVal.Cmp(rVal) < 0)
+	default:
 		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
 	}
+}
+
+func evalFloatInfixExpr(op string, left, right object.Object) object.Object {
+	lVal := toFloat(left)
+	rVal := toFloat(right)
+
+	switch op {
+	case "+":
+		return &object.Float{Value: lVal + rVal}
+	case "-":
+		return &object.Float{Value: lVal - rVal}
+	case "*":
+		return &object.Float{Value: lVal * rVal}
+	case "/":
+		return &object.Float{Value: lVal / rVal}
+	case "==":
+		return nativeBoolToBoolObj(lVal == rVal)
+	case "!=":
+		return nativeBoolToBoolObj(lVal != rVal)
+	case ">=":
+		return nativeBoolToBoolObj(lVal >= rVal)
+	case "<=":
+		return nativeBoolToBoolObj(lVal <= rVal)
+	case ">":
+		return nativeBoolToBoolObj(lVal > rVal)
+	case "<":
+		return nativeBoolToBoolObj(lVal < rVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
 
+func evalStringInfixExpr(op string, left, right object.Object) object.Object {
 	lVal := left.(*object.String).Value
 	rVal := right.(*object.String).Value
 
+	switch op {
+	case "+":
+		return &object.String{Value: lVal + rVal}
+	case "==":
+		return nativeBoolToBoolObj(lVal == rVal)
+	case "!=":
+		return nativeBoolToBoolObj(lVal != rVal)
+	case ">=":
+		return nativeBoolToBoolObj(lVal >= rVal)
+	case "<=":
+		return nativeBoolToBoolObj(lVal <= rVal)
+	case ">":
+		return nativeBoolToBoolObj(lVal > rVal)
+	case "<":
+		return nativeBoolToBoolObj(lVal < rVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func evalStringRepeat(str, count object.Object) object.Object {
+	n := count.(*object.Integer).Value
+	if n < 0 {
+		return newError("negative repeat count: %d", n)
+	}
+
+	value := str.(*object.String).Value
+	if len(value) > 0 && n > maxStringLen/int64(len(value)) {
+		return newError("repeated string too long: %d * %d bytes", n, len(value))
+	}
+
 	return &object.String{
-		Value: lVal + rVal,
+		Value: strings.Repeat(value, int(n)),
+	}
+}
+
+// evalInExpr is membership, substring for STRING, key for HASH and element for ARRAY
+func evalInExpr(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.String:
+		sub, ok := left.(*object.String)
+		if !ok {
+			return newError("unknown operator: %s in %s", left.Type(), right.Type())
+		}
+		return nativeBoolToBoolObj(strings.Contains(right.Value, sub.Value))
+	case *object.Hash:
+		_, ok := right.Get(left)
+		return nativeBoolToBoolObj(ok)
+	case *object.Array:
+		for _, elem := range right.Elems {
+			if object.Equals(left, elem) {
+				return TRUE
+			}
+		}
+		return FALSE
+	default:
+		return newError("unknown operator: %s in %s", left.Type(), right.Type())
 	}
 }
 
@@ -397,14 +701,19 @@ func evalBoolInfixExpr(op string, left, right object.Object) object.Object {
 }
 
 func evalNegOpExpr(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.IntegerFromBig(new(big.Int).Neg(toBig(right)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return object.IntegerFromBig(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	val := right.(*object.Integer).Value
-	return &object.Integer{
-		Value: -val,
-	}
 }
 
 func evalNotOpExpr(right object.Object) object.Object {
@@ -1,7 +1,17 @@
 package evaluator
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Aergiaaa/simplescript/lexer"
 	"github.com/Aergiaaa/simplescript/object"
@@ -41,6 +51,18 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{[2024, 1]: 5}[[2024, 1]]`,
+			5,
+		},
+		{
+			`{[2024, 1]: 5}[[2024, 2]]`,
+			nil,
+		},
+		{
+			`let k = [1, ["a", true]]; {k: 5}[[1, ["a", true]]]`,
+			5,
+		},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -70,25 +92,41 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaled, evaled)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key object.Object
+		val int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(res.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(res.Pairs))
+	if res.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", res.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := res.Pairs[expectedKey]
+	for _, tt := range expected {
+		pair, ok := res.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
-		testIntegerObject(t, pair.Val, expectedValue)
+		testIntegerObject(t, pair.Val, tt.val)
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	input := `let two = "two";
+	{"z": 1, two: 2, "a": 3, 10: 4, "z": 5, false: 6}`
+
+	// run it a few times, map iteration order would show up as flakiness
+	for range 20 {
+		evaled := testEval(input)
+		if evaled.Inspect() != "{z: 5, two: 2, a: 3, 10: 4, false: 6}" {
+			t.Fatalf("hash in wrong order. got=%s", evaled.Inspect())
+		}
 	}
 }
 
@@ -187,6 +225,17 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("hello world")`, 11},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments, got=2, want=1"},
+		{`compare("a", "b")`, -1},
+		{`compare("b", "a")`, 1},
+		{`compare("Go", "go")`, -1},
+		{`compare("Go", "go", true)`, 0},
+		{`compare("ÉCOLE", "école", true)`, 0},
+		{`compare("a", 1)`, "argument to `compare` must be STRING, got INTEGER"},
+		{`head(1)`, "argument to `head` must be ARRAY, got INTEGER"},
+		{`tail(1)`, "argument to `tail` must be ARRAY, got INTEGER"},
+		{`killHead("a")`, "argument to `killHead` must be ARRAY, got STRING"},
+		{`push(1, 2)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`push([1])`, "wrong number of arguments, got=1, want=2"},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -208,6 +257,809 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestPutsUsesContextStdout(t *testing.T) {
+	var out bytes.Buffer
+
+	ctx := object.InitContext()
+	ctx.Stdout = &out
+
+	evaled := testEvalContext(ctx, `puts("hello", 1 + 1)`)
+
+	testNullObject(t, evaled)
+	if out.String() != "hello\n2\n" {
+		t.Errorf("wrong output, got=%q", out.String())
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let gen = ft(n) { let sq = ft(x) { x * x }; yield sq(n); yield sq(n + 1) };
+			collect(gen(2))`,
+			"[4, 9]",
+		},
+		{
+			`let abc = ft() { yield "a"; yield "b"; yield "c" };
+			collect(abc())`,
+			"[a, b, c]",
+		},
+		{
+			`let gen = ft() { yield 1; return 5; yield 2 };
+			collect(gen())`,
+			"[1]",
+		},
+		{
+			`let gen = ft() { yield 1; yield 2 };
+			let g = gen();
+			[next(g), next(g), next(g), next(g, "done")]`,
+			"[1, 2, null, done]",
+		},
+		{
+			`let nat = ft(from) { yield from; let rest = nat(from + 1); yield next(rest); yield next(rest) };
+			collect(nat(5))`,
+			"[5, 6, 7]",
+		},
+		{
+			`let nat = ft() { let loop = ft(i) { yield i; loop(i + 1) }; loop(0); yield -1 };
+			collect(take(nat(), 3))`,
+			"[0, 1, 2]",
+		},
+		{
+			`let evens = ft() { each(range(1000000000000), ft(i) { yield i * 2 }); yield -1 };
+			collect(take(evens(), 4))`,
+			"[0, 2, 4, 6]",
+		},
+		{
+			`let gen = ft() { let loop = ft(i) { if (i < 2) { yield i; loop(i + 1) } }; loop(0); yield -1 };
+			collect(gen())`,
+			"[0, 1, -1]",
+		},
+		{
+			`let bad = ft() { yield 1; 1 + true };
+			collect(bad())`,
+			"ERROR: type mismatch: INTEGER + BOOL",
+		},
+		{
+			`let g = ft(a, b) { yield a + b }; g(1)`,
+			"ERROR: wrong number of arguments, got=1, want=2",
+		},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestLazyIteration(t *testing.T) {
+	count := "let count = ft(n) { yield n; yield n + 1; yield n + 2; yield n + 3; yield n + 4; yield n + 5 }; "
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"collect(range(5))", "[0, 1, 2, 3, 4]"},
+		{"collect(range(2, 5))", "[2, 3, 4]"},
+		{"collect(range(10, 0, -3))", "[10, 7, 4, 1]"},
+		{"range(3)", "range(0, 3, 1)"},
+		{"range(1, 2, 0)", "ERROR: range step must not be zero"},
+		{"collect(take(range(9223372036854775806, 9223372036854775807, 4611686018427387904), 5))", "[9223372036854775806]"},
+		{"collect(range(-9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1))", "[-9223372036854775807]"},
+		{"collect(range(0, 9223372036854775807, 4611686018427387904))", "[0, 4611686018427387904]"},
+		{"map([1, 2, 3], ft(x) { x * 2 })", "[2, 4, 6]"},
+		{"map(range(3), ft(x) { x * 2 })", "iterator"},
+		{"collect(map(range(3), ft(x) { x * 2 }))", "[0, 2, 4]"},
+		{`map("héy", ft(c) { c + c })`, "[hh, éé, yy]"},
+		{`map({"a": 1, "b": 2}, ft(kv) { kv[0] })`, "[a, b]"},
+		{"filter([1, 2, 3, 4], ft(x) { x > 2 })", "[3, 4]"},
+		{"take([1, 2, 3], 2)", "[1, 2]"},
+		{"take(range(100), 0)", "iterator"},
+		{"collect(take(filter(range(1000000000), ft(x) { x > 5 }), 3))", "[6, 7, 8]"},
+		{count + "collect(take(map(count(10), ft(x) { x * x }), 3))", "[100, 121, 144]"},
+		{"zip([1, 2, 3], \"ab\")", "[[1, a], [2, b]]"},
+		{"collect(zip(range(100), [\"a\", \"b\"]))", "[[0, a], [1, b]]"},
+		{"collect(map(range(3), ft(x) { x + true }))", "ERROR: type mismatch: INTEGER + BOOL"},
+		{"map([1], ft(x, y) { x })", "ERROR: wrong number of arguments, got=1, want=2"},
+		{"let it = iter([1, 2]); next(it); collect(it)", "[2]"},
+		{"map(1, ft(x) { x })", "ERROR: argument to `map` must be iterable, got INTEGER"},
+		{"next([1])", "ERROR: argument to `next` must be ITERATOR or GENERATOR, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestTasks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let t = spawn ft(x) { x * 2 }(21); await(t)", "42"},
+		{"let add = ft(a, b) { a + b }; let ts = map([0, 1, 2, 3], ft(i) { spawn add(i, 10) }); map(ts, await)", "[10, 11, 12, 13]"},
+		{"await(spawn ft() { 1 + true }())", "ERROR: type mismatch: INTEGER + BOOL"},
+		{"spawn undefined(1)", "ERROR: identifier not found: undefined"},
+		{"let ch = chan(1); send(ch, 5); recv(ch)", "5"},
+		{"let ch = chan(); spawn ft() { send(ch, 1); send(ch, 2); close(ch) }(); collect(ch)", "[1, 2]"},
+		{"let ch = chan(2); send(ch, 1); close(ch); [recv(ch), recv(ch)]", "[1, null]"},
+		{"let ch = chan(1); close(ch); send(ch, 1)", "ERROR: send on closed channel"},
+		{"let ch = chan(); let w = spawn ft() { send(ch, 7) }(); let v = recv(ch); await(w); v", "7"},
+		{`let a = chan(); let b = chan(1); send(b, "b"); select([a, b])`, "[1, b]"},
+		{"let a = chan(); let b = chan(); close(b); select([a, b])", "[1, null]"},
+		{"select([chan()], 10)", "null"},
+		{`select([chan()], duration("10ms"))`, "null"},
+		{"select([chan()], 9223372036854775807)", "ERROR: argument to `select` out of duration range: 9223372036854775807"},
+		{"chan(-1)", "ERROR: argument to `chan` must be a non-negative INTEGER, got -1"},
+		{"chan(9223372036854775807)", "ERROR: buffer of `chan` too large: 9223372036854775807, the maximum is 1048576"},
+		{"chan(1000000000000)", "ERROR: buffer of `chan` too large: 1000000000000, the maximum is 1048576"},
+		{"await(1)", "ERROR: argument to `await` must be TASK, got INTEGER"},
+		{"select([])", "ERROR: argument to `select` must be a non-empty ARRAY of CHANNEL, got []"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestChannelIterationCancel(t *testing.T) {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := object.InitContext()
+	ctx.Context = cancelCtx
+
+	done := make(chan object.Object)
+	go func() {
+		done <- testEvalContext(ctx, `collect(chan())`)
+	}()
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	select {
+	case res := <-done:
+		testErrorObject(t, res, "execution cancelled: context canceled")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("iterating a channel did not stop on cancel")
+	}
+}
+
+func TestMutableCollections(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; append!(a, 2, 3); a", "[1, 2, 3]"},
+		{"let a = []; let add = ft(n) { if (n > 0) { append!(a, n); add(n - 1) } }; add(3); a", "[3, 2, 1]"},
+		{"let a = [1, 2]; [pop!(a), a]", "[2, [1]]"},
+		{"pop!([])", "null"},
+		{"let a = [1, 3]; insert!(a, 1, 2); insert!(a, 3, 4)", "[1, 2, 3, 4]"},
+		{"let a = [1, 2, 3]; [remove!(a, 0), a]", "[1, [2, 3]]"},
+		{"let a = [1, 2]; set!(a, 1, 5)", "[1, 5]"},
+		{`let h = {"a": 1}; set!(h, "b", 2); set!(h, "a", 3)`, "{a: 3, b: 2}"},
+		{`let h = {"a": 1, "b": 2}; [delete!(h, "a"), delete!(h, "z"), h]`, "[1, null, {b: 2}]"},
+
+		// let and calls share the collection, non ! builtins copy
+		{"let a = [1]; let b = a; append!(b, 2); a", "[1, 2]"},
+		{"let a = [1]; let f = ft(x) { append!(x, 2) }; f(a); a", "[1, 2]"},
+		{"let a = [1]; let b = push(a, 2); [a, b]", "[[1], [1, 2]]"},
+		{"let a = [1, 2]; let b = a[0:1]; append!(b, 9); a", "[1, 2]"},
+		{`let k = [1]; let h = {k: "v"}; append!(k, 2); [h[[1]], h[k]]`, "[v, null]"},
+
+		// collections holding themselves
+		{"let a = [1]; append!(a, a); a", "[1, [...]]"},
+		{`let h = {"a": 1}; set!(h, "self", [h])`, "{a: 1, self: [{...}]}"},
+		{"let a = [1]; append!(a, a); let b = [1]; append!(b, b); [a == b, a == [1, [1]]]", "[true, false]"},
+		{"let a = [1]; append!(a, a); flatten(a)", "ERROR: cannot flatten cyclic ARRAY"},
+		{"let a = [1]; append!(a, a); flatten(a, 1)", "ERROR: cannot flatten cyclic ARRAY"},
+		{"let a = [1]; append!(a, [a]); json_stringify(a)", "ERROR: cannot encode cyclic ARRAY as json"},
+		{"let a = [1]; append!(a, a); {a: 1}", "ERROR: unusable as hash key: ARRAY"},
+
+		{"insert!([1], 2, 0)", "ERROR: index out of bound: 2"},
+		{"remove!([], 0)", "ERROR: index out of bound: 0"},
+		{`set!([1], "a", 0)`, "ERROR: argument to `set!` must be INTEGER, got STRING"},
+		{"set!({}, [], 1)", "{[]: 1}"},
+		{"set!({}, ft(x) { x }, 1)", "ERROR: unusable as hash key: FUNCTION"},
+		{"append!(1, 2)", "ERROR: argument to `append!` must be ARRAY, got INTEGER"},
+		{"delete!([1], 0)", "ERROR: argument to `delete!` must be HASH, got ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestCollectionFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"reduce([1, 2, 3, 4], ft(acc, x) { acc + x })", "10"},
+		{"reduce(range(100000), ft(acc, x) { acc + x }, 0)", "4999950000"},
+		{`reduce([], ft(acc, x) { acc + x }, "none")`, "none"},
+		{"reduce([], ft(acc, x) { acc + x })", "ERROR: reduce of empty iterable with no initial value"},
+		{"reduce([1, 2], ft(acc, x) { acc + true })", "ERROR: type mismatch: INTEGER + BOOL"},
+		{"let out = []; each([1, 2], ft(x) { append!(out, x * 10) }); out", "[10, 20]"},
+		{"each(range(3), ft(x) { x + true })", "ERROR: type mismatch: INTEGER + BOOL"},
+		{"find([1, 5, 7], ft(x) { x > 2 })", "5"},
+		{"find(range(1000000000), ft(x) { x * x > 50 })", "8"},
+		{"find([1], ft(x) { x > 2 })", "null"},
+		{"[any([1, 2], ft(x) { x > 1 }), any([], ft(x) { true }), any([false, 0])]", "[true, false, true]"},
+		{"[all([1, 2], ft(x) { x > 1 }), all([]), all([1, true])]", "[false, true, true]"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["b", "a", "C"])`, "[C, a, b]"},
+		{"sort([2, float(1), 9223372036854775807 + 1])", "[1.0, 2, 9223372036854775808]"},
+		{"sort([3, 1, 2], ft(a, b) { b - a })", "[3, 2, 1]"},
+		{`sort([[2, "a"], [1, "b"], [2, "c"]], ft(a, b) { a[0] - b[0] })`, "[[1, b], [2, a], [2, c]]"},
+		{"let a = [2, 1]; sort(a); a", "[2, 1]"},
+		{`sort([1, "a"])`, "ERROR: type mismatch: STRING < INTEGER"},
+		{"sort([1, 2], ft(a, b) { true })", "ERROR: comparator of `sort` must return INTEGER, got BOOL"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{`reverse("héllo")`, "olléh"},
+		{"reverse(range(3))", "[2, 1, 0]"},
+		{"flatten([1, [2, [3, [4]]], []])", "[1, 2, 3, 4]"},
+		{"flatten([1, [2, [3, [4]]]], 1)", "[1, 2, [3, [4]]]"},
+		{"uniq([1, 2, 1, [1], [1], 3, 2])", "[1, 2, [1], 3]"},
+		{"let f = ft(x) { x }; len(uniq([f, f, 1, ft(x) { x }]))", "3"},
+		{`group_by([1, 2, 3, 4, 5], ft(x) { if (x > 2) { "big" } else { "small" } })`, "{small: [1, 2], big: [3, 4, 5]}"},
+		{"group_by([1], ft(x) { ft() { x } })", "ERROR: unusable as hash key: FUNCTION"},
+		{"each(1, ft(x) { x })", "ERROR: argument to `each` must be iterable, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  a b   c ")`, "[a, b, c]"},
+		{`join(["a", 1, true], "-")`, "a-1-true"},
+		{`join(range(3))`, "012"},
+		{`trim("  hi  ")`, "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`[upper("héllo"), lower("ÉCOLE")]`, "[HÉLLO, école]"},
+		{`replace("aaa", "a", "b")`, "bbb"},
+		{`replace("aaa", "a", "b", 2)`, "bba"},
+		{`[contains("hello", "ell"), contains("hello", "z")]`, "[true, false]"},
+		{`[starts_with("hello", "he"), ends_with("hello", "lo"), ends_with("hello", "he")]`, "[true, true, false]"},
+		{`[index_of("héllo", "llo"), index_of("hello", "z")]`, "[2, -1]"},
+		{`pad_left("7", 3, "0")`, "007"},
+		{`pad_right("ab", 7, "xy")`, "abxyxyx"},
+		{`pad_left("héllo", 3)`, "héllo"},
+		{`"[" + pad_right("é", 3) + "]"`, "[é  ]"},
+		{`chars("héy")`, "[h, é, y]"},
+		{`format("%s is %d years, %.2f%%", "Ann", 30, float(1) / 3)`, "Ann is 30 years, 0.33%"},
+		{`format("%5d|%-4s|%x|%q|%t|%v", 42, "ab", 255, "q", true, [1, "a"])`, "   42|ab  |ff|\"q\"|true|[1, a]"},
+		{`format("%d", 9223372036854775807 + 1)`, "9223372036854775808"},
+		{`format("%d", "a")`, "ERROR: format: %d needs INTEGER, got STRING"},
+		{`format("%d %d", 1)`, "ERROR: format: missing argument for %d"},
+		{`format("%d", 1, 2)`, "ERROR: format: too many arguments, got=2, used=1"},
+		{`format("%y", 1)`, "ERROR: format: unknown verb %y"},
+		{`parse_int("-42")`, "-42"},
+		{`parse_int("ff", 16)`, "255"},
+		{`parse_int("0b101", 0)`, "5"},
+		{`parse_int("99999999999999999999")`, "99999999999999999999"},
+		{`parse_int("4x")`, `ERROR: could not parse "4x" as integer`},
+		{`parse_int("1", 40)`, "ERROR: invalid base for `parse_int`: 40"},
+		{`parse_float("2.5e3")`, "2500.0"},
+		{`parse_float("abc")`, `ERROR: could not parse "abc" as float`},
+		{`split(1, ",")`, "ERROR: argument to `split` must be STRING, got INTEGER"},
+		{`upper("a", "b")`, "ERROR: wrong number of arguments, got=2, want=1"},
+		{`pad_left("a", "3")`, "ERROR: argument to `pad_left` must be INTEGER, got STRING"},
+		{`pad_left("a", 9223372036854775807)`, "ERROR: width for `pad_left` too large: 9223372036854775807"},
+		{`pad_left("a", 1073741824, "é")`, "ERROR: width for `pad_left` too large: 1073741824"},
+		{`pad_right("a", 6, "xyz")`, "axyzxy"},
+		{`join(1)`, "ERROR: argument to `join` must be iterable, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestMathFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[abs(-3), abs(3), abs(float(-1) / 2)]", "[3, 3, 0.5]"},
+		{"abs(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"[min(3, 1, 2), max(3, 1, 2), min([4, 5]), max(range(10))]", "[1, 3, 4, 9]"},
+		{`[min("b", "a"), max(1, float(5) / 2)]`, "[a, 2.5]"},
+		{"min([])", "ERROR: argument to `min` must not be empty"},
+		{"[max(5), min(float(1) / 2)]", "[5, 0.5]"},
+		{`max(1, "a")`, "ERROR: type mismatch: STRING < INTEGER"},
+		{"[clamp(5, 0, 3), clamp(-1, 0, 3), clamp(2, 0, 3)]", "[3, 0, 2]"},
+		{"clamp(1, 3, 0)", "ERROR: `clamp` bounds out of order: 3 > 0"},
+		{"[pow(2, 10), pow(2, 64), pow(2, -1), pow(float(9), float(1) / 2)]", "[1024, 18446744073709551616, 0.5, 3.0]"},
+		{"[sqrt(16), sqrt(2) * sqrt(2) - 2 < float(1) / 1000000]", "[4.0, true]"},
+		{"[floor(float(7) / 2), ceil(float(7) / 2), round(float(7) / 2), round(float(-7) / 2), floor(4)]", "[3, 4, 4, -4, 4]"},
+		{"round(sqrt(-1))", "ERROR: cannot convert NaN to integer"},
+		{"[sin(0), cos(0), round(tan(PI / 4) * 1000), atan2(0, 1)]", "[0.0, 1.0, 1000, 0.0]"},
+		{"[log(E), log(8, 2), log2(8), log10(1000), exp(0)]", "[1.0, 3.0, 3.0, 3.0, 1.0]"},
+		{"round(asin(1) * 2 * 1000) == round(PI * 1000)", "true"},
+		{"let PI = 3; PI", "3"},
+		{`sqrt("4")`, "ERROR: argument to `sqrt` must be a number, got STRING"},
+		{"seed(7); let a = [random(), random(100)]; seed(7); a == [random(), random(100)]", "true"},
+		{"all(map(range(200), ft(i) { let r = random(-2, 2); if (r < -2) { false } else { r <= 2 } }))", "true"},
+		{"let r = random(); [r >= 0, r < 1]", "[true, true]"},
+		{"random(0)", "ERROR: empty range for `random`: 0 to -1"},
+		{"random(3, 1)", "ERROR: empty range for `random`: 3 to 1"},
+		{"random(-9223372036854775807 - 1)", "ERROR: empty range for `random`: 0 to -9223372036854775809"},
+		{"random(-9223372036854775807 - 1, 9223372036854775807) <= 9223372036854775807", "true"},
+		{"random(9223372036854775807, 9223372036854775807)", "9223372036854775807"},
+		{"all(map(range(50), ft(i) { random(-9223372036854775807, -9223372036854775806) < -9223372036854775805 }))", "true"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	// strings have no escapes, so the json goes in through src
+	tests := []struct {
+		src      string
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": [true, null, 2.5, "x"], "c": {}}`, "json_parse(src)", "{b: 1, a: [true, null, 2.5, x], c: {}}"},
+		{`12345678901234567890`, "json_parse(src)", "12345678901234567890"},
+		{`[1e3, -0.5, 2.0]`, "json_parse(src)", "[1000.0, -0.5, 2.0]"},
+		{`  "é\u00e9\"" `, "json_parse(src)", `éé"`},
+		{`{"a": 1, "b": 0, "a": 2}`, "json_parse(src)", "{a: 2, b: 0}"},
+		{`[1, 2`, "json_parse(src)", "ERROR: invalid json at offset 5: unexpected end of JSON input"},
+		{`{"a" 1}`, "json_parse(src)", "ERROR: invalid json at offset 6: invalid character '1' after object key"},
+		{`[1]  [2]`, "json_parse(src)", "ERROR: invalid json at offset 5: extra data after value"},
+		{``, "json_parse(src)", "ERROR: invalid json at offset 0: unexpected end of JSON input"},
+		{`q"`, `json_stringify({"z": [1, float(1) / 2, src], "a": if (false) { 1 }, 1: true})`, `{"z":[1,0.5,"q\""],"a":null,"1":true}`},
+		{``, `json_stringify([float(2), 9223372036854775807 + 1])`, "[2.0,9223372036854775808]"},
+		{``, `json_parse(json_stringify({"b": [1, {"c": "d"}], "a": 2}))`, "{b: [1, {c: d}], a: 2}"},
+		{``, `json_stringify({"a": [1]}, 2)`, "{\n  \"a\": [\n    1\n  ]\n}"},
+		{"\t", `json_stringify({"a": 1}, src)`, "{\n\t\"a\": 1\n}"},
+		{``, `json_stringify(ft(x) { x })`, "ERROR: cannot encode FUNCTION as json"},
+		{``, `json_stringify({[1]: 2})`, "ERROR: cannot encode ARRAY hash key as json"},
+		{``, `json_stringify(sqrt(-1))`, "ERROR: cannot encode NaN as json"},
+	}
+	for _, tt := range tests {
+		env := object.InitEnv()
+		env.Set("src", &object.String{Value: tt.src})
+
+		evaluated := testEvalEnv(env, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s with %q. got=%s, want=%s", tt.input, tt.src, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestFileBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := object.InitContext()
+	ctx.Caps = object.CapFiles
+	ctx.FileRoot = dir
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`write_file("a.txt", "one")`, "null"},
+		{`append_file("a.txt", " two"); read_file("a.txt")`, "one two"},
+		{`write_file("a.txt", "new"); read_file("a.txt")`, "new"},
+		{`append_file("sub/b.txt", "b"); read_file("sub/b.txt")`, "b"},
+		{`list_dir()`, "[a.txt, sub/]"},
+		{`list_dir("sub")`, "[b.txt]"},
+		{`[exists("a.txt"), exists("missing")]`, "[true, false]"},
+		{`remove("a.txt"); exists("a.txt")`, "false"},
+		{`read_file("missing")`, "ERROR: read_file: missing: no such file or directory"},
+		{`read_file("../secret")`, "ERROR: read_file: ../secret: path escapes from parent"},
+		{`exists("sub/../../secret")`, "ERROR: exists: sub/../../secret: path escapes from parent"},
+		{`write_file("/etc/x", "")`, "ERROR: write_file: /etc/x: path escapes from parent"},
+		{`write_file("a.txt", 1)`, "ERROR: argument to `write_file` must be STRING, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEvalContext(ctx, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+
+	evaluated := testEval(`read_file("a.txt")`)
+	testErrorObject(t, evaluated, "`read_file` is not allowed, file access is disabled")
+}
+
+func TestProcessBuiltins(t *testing.T) {
+	ctx := object.InitContext()
+	ctx.Args = []string{"a", "-b"}
+	ctx.Env = []string{"HOME=/home/ann", "EMPTY=", "X=1", "X=2"}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"args()", "[a, -b]"},
+		{`[env("HOME"), env("EMPTY"), env("X"), env("MISSING")]`, "[/home/ann, , 2, null]"},
+		{"env()", "{HOME: /home/ann, EMPTY: , X: 2}"},
+		{"exit(3); 1", "ERROR: exit status 3"},
+		{"let f = ft() { map([1, 2], ft(x) { exit(x) }) }; f(); 1", "ERROR: exit status 1"},
+		{"await(spawn ft() { exit() }())", "ERROR: exit status 0"},
+		{`exit("1")`, "ERROR: argument to `exit` must be INTEGER, got STRING"},
+		{"exit(256)", "ERROR: argument to `exit` must be an INTEGER from 0 to 255, got 256"},
+		{"exit(-1)", "ERROR: argument to `exit` must be an INTEGER from 0 to 255, got -1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEvalContext(ctx, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+
+	if args := testEval("args()"); args.Inspect() != "[]" {
+		t.Errorf("args without a host. got=%s", args.Inspect())
+	}
+}
+
+func TestInputBuiltins(t *testing.T) {
+	tests := []struct {
+		stdin    string
+		input    string
+		expected string
+	}{
+		{"a\nb\r\nc", "[read_line(), read_line(), read_line(), read_line()]", "[a, b, c, null]"},
+		{"a\n\nb\n", "collect(lines())", "[a, , b]"},
+		{"one\ntwo\nthree\n", "let first = collect(take(lines(), 1)); [first, read_all()]", "[[one], two\nthree\n]"},
+		{"x y\nz\n", "read_line(); read_all()", "z\n"},
+		{"", "[read_line(), read_all(), collect(lines())]", "[null, , []]"},
+		{"3\n4\n", "reduce(map(lines(), int), ft(a, b) { a + b })", "7"},
+		{"", "read_line(1)", "ERROR: wrong number of arguments, got=1, want=0"},
+	}
+	for _, tt := range tests {
+		ctx := object.InitContext()
+		ctx.Stdin = strings.NewReader(tt.stdin)
+
+		evaluated := testEvalContext(ctx, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestRegex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`re("\d+")`, `re("\\d+")`},
+		{`re("a+") == re("a+")`, "true"},
+		{`[match(re("^h.llo$"), "héllo"), match("\d", "abc")]`, "[true, false]"},
+		{`find_all(re("\d+"), "a1 b22 c333")`, "[1, 22, 333]"},
+		{`find_all("x", "abc")`, "[]"},
+		{`captures(re("(?P<year>\d{4})-(?P<month>\d\d)"), "on 2024-05-01")`, "{year: 2024, month: 05}"},
+		{`captures(re("(\w+)@(\w+)?"), "mail ann@")`, "[ann@, ann, null]"},
+		{`captures("z", "abc")`, "null"},
+		{`replace("a1b22", re("\d+"), "#")`, "a#b#"},
+		{`replace("ann bob", re("(\w)(\w*)"), "${2}$1")`, "nna obb"},
+		{`replace("a1b22", re("\d+"), ft(m) { len(m) * 10 })`, "a10b20"},
+		{`replace("ab", re("\w"), upper)`, "AB"},
+		{`replace("a1", re("\d"), ft(m) { m + true })`, "ERROR: type mismatch: STRING + BOOL"},
+		{`replace("aaa", "a", "b", 1)`, "baa"},
+		{`split("a1b22c", re("\d+"))`, "[a, b, c]"},
+		{`split("", re(","))`, "[]"},
+		{`re("(")`, "ERROR: invalid regex: error parsing regexp: missing closing ): `(`"},
+		{`match(1, "a")`, "ERROR: argument to `match` must be REGEX or STRING, got INTEGER"},
+		{`find_all(re("a"))`, "ERROR: wrong number of arguments, got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestTimeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"now()", "2024-05-01T12:00:00Z"},
+		{"unix()", "1714564800"},
+		{"let start = now(); sleep(1500); now() - start", "1.5s"},
+		{`sleep(duration("2m")); now()`, "2024-05-01T12:02:00Z"},
+		{`format_time(now() + duration("36h"), "2006-01-02 15:04")`, "2024-05-03 00:00"},
+		{`parse_time("2024-02-29 08:30", "2006-01-02 15:04")`, "2024-02-29T08:30:00Z"},
+		{`unix(parse_time("1970-01-02T00:00:00Z"))`, "86400"},
+		{`parse_time("2024-13-01", "2006-01-02")`, `ERROR: could not parse "2024-13-01" as time: parsing time "2024-13-01": month out of range`},
+		{`[duration("1h30m"), duration(250), millis(duration("2s"))]`, "[1h30m0s, 250ms, 2000]"},
+		{`duration("1h") * 2 + duration("30m") / 3 - duration(1000)`, "2h9m59s"},
+		{`[3 * duration("1s"), duration("1m") / duration("1s")]`, "[3s, 60.0]"},
+		{`[duration("1s") < duration("2s"), now() > now() - duration(1), now() == now()]`, "[true, true, true]"},
+		{`now() + now()`, "ERROR: unknown operator: TIME + TIME"},
+		{`now() + 1`, "ERROR: type mismatch: TIME + INTEGER"},
+		{`duration("1s") / 0`, "ERROR: division by zero"},
+		{`duration("soon")`, `ERROR: could not parse "soon" as duration`},
+		{`sleep("1s")`, "ERROR: argument to `sleep` must be DURATION or INTEGER, got STRING"},
+		{"duration(9223372036854775807)", "ERROR: argument to `duration` out of duration range: 9223372036854775807"},
+		{"duration(1000) * 100000000000000", "ERROR: duration overflow: 1s * 100000000000000"},
+		{"100000000000000 * duration(1000)", "ERROR: duration overflow: 100000000000000 * 1s"},
+		{"duration(1) / (float(1) / 100000000000000)", "ERROR: duration overflow: 1ms / 1e-14"},
+		{`duration("2000000h") + duration("2000000h")`, "ERROR: duration overflow: 2000000h0m0s + 2000000h0m0s"},
+		{`duration("-2000000h") - duration("2000000h")`, "ERROR: duration overflow: -2000000h0m0s - 2000000h0m0s"},
+		{`duration("2000000h") - duration("2000000h")`, "0s"},
+		{"sleep(-9223372036854775807)", "ERROR: argument to `sleep` out of duration range: -9223372036854775807"},
+	}
+	for _, tt := range tests {
+		ctx := object.InitContext()
+		ctx.Clock = object.InitFrozenClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+		evaluated := testEvalContext(ctx, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestEncodingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`base64_encode("héllo?>")`, "aMOpbGxvPz4="},
+		{`base64_encode("héllo?>", true)`, "aMOpbGxvPz4="},
+		{`base64_encode("??>")`, "Pz8+"},
+		{`base64_encode("??>", true)`, "Pz8-"},
+		{`base64_decode("aMOpbGxvPz4=")`, "héllo?>"},
+		{`base64_decode("Pz8-", true)`, "??>"},
+		{`base64_decode("!!")`, `ERROR: could not decode "!!" as base64: illegal base64 data at input byte 0`},
+		{`hex_encode("hi")`, "6869"},
+		{`hex_decode("6869")`, "hi"},
+		{`hex_decode("zz")`, `ERROR: could not decode "zz" as hex: encoding/hex: invalid byte: U+007A 'z'`},
+		{`url_encode("a b&c=é")`, "a+b%26c%3D%C3%A9"},
+		{`url_encode({"q": "a b", "n": 1})`, "q=a+b&n=1"},
+		{`url_decode("a+b%26c")`, "a b&c"},
+		{`url_decode("%zz")`, `ERROR: could not decode "%zz" as url: invalid URL escape "%zz"`},
+		{`md5("abc")`, "900150983cd24fb0d6963f7d28e17f72"},
+		{`sha1("abc")`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{`sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`len(sha512(""))`, "128"},
+		{`hmac("sha256", "key", "The quick brown fox jumps over the lazy dog")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`hmac("md5", "key", "The quick brown fox jumps over the lazy dog")`, "80070713463e7749b90c2dc24911e275"},
+		{`base64_encode(hex_decode(sha1("abc")))`, "qZk+NkcGgWq6PiVxeFDCbJzQ2J0="},
+		{`hmac("crc", "k", "m")`, "ERROR: unknown hash for `hmac`: crc"},
+		{`sha256(1)`, "ERROR: argument to `sha256` must be STRING, got INTEGER"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		src      string
+		input    string
+		expected string
+	}{
+		{"a,b\n1,\"x, \"\"y\"\"\"\n", "csv_parse(src)", `[[a, b], [1, x, "y"]]`},
+		{"name,age\nann,30\nbob,4\n", `csv_parse(src, {"header": true})`, "[{name: ann, age: 30}, {name: bob, age: 4}]"},
+		{"name;age\nann;30\n", `csv_parse(src, {"header": true, "separator": ";"})`, "[{name: ann, age: 30}]"},
+		{"", `csv_parse(src, {"header": true})`, "[]"},
+		{"a,b\n1\n", "csv_parse(src)", "ERROR: csv_parse: record on line 2: wrong number of fields"},
+		{"a,\"b\n", "csv_parse(src)", `ERROR: csv_parse: parse error on line 1, column 6: extraneous or missing " in quoted-field`},
+		{"", `csv_parse("a", {"headers": true})`, "ERROR: unknown option for `csv_parse`: headers"},
+		{"", `csv_parse("a", {"separator": "::"})`, `ERROR: separator of ` + "`csv_parse`" + ` must be a single character, got "::"`},
+		{"", `csv_format([["a", "b"], [1, "x, y"], [true, if (false) { 1 }]])`, "a,b\n1,\"x, y\"\ntrue,\n"},
+		{"q\"", `csv_format([[src]])`, "\"q\"\"\"\n"},
+		{"", `csv_format([{"n": "ann", "a": 3}, {"a": 4, "n": "bob"}, {"n": "cy"}])`, "n,a\nann,3\nbob,4\ncy,\n"},
+		{"", `csv_format([{"n": 1}], {"header": false, "separator": "|"})`, "1\n"},
+		{"\n", `csv_format([[1, 2]], {"separator": src})`, `ERROR: separator of ` + "`csv_format`" + ` must not be a quote or line break, got "\n"`},
+		{"\"", `csv_parse("a", {"separator": src})`, `ERROR: separator of ` + "`csv_parse`" + ` must not be a quote or line break, got "\""`},
+		{"", `csv_format([1])`, "ERROR: rows of `csv_format` must be ARRAY or HASH, got INTEGER"},
+		{"x,y\n1,2\n", `csv_format(csv_parse(src, {"header": true}))`, "x,y\n1,2\n"},
+	}
+	for _, tt := range tests {
+		env := object.InitEnv()
+		env.Set("src", &object.String{Value: tt.src})
+
+		evaluated := testEvalEnv(env, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s with %q. got=%q, want=%q", tt.input, tt.src, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if r.URL.Path == "/big" {
+			w.Write(bytes.Repeat([]byte("x"), maxBodySize+1))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "%s %s %s", r.URL.Path, r.Header.Get("X-Token"), body)
+	}))
+	defer srv.Close()
+
+	ctx := object.InitContext()
+	ctx.Caps = object.CapNet
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let res = http_get(url + "/a"); [res["status"], res["headers"]["X-Method"], res["body"]]`, "[202, GET, /a  ]"},
+		{`http_request({"url": url + "/b", "method": "post", "headers": {"X-Token": "t"}, "body": "hi"})["body"]`, "/b t hi"},
+		{`http_request({"url": url + "/slow", "timeout": 20})`, "context deadline exceeded"},
+		{`http_request({"method": "GET"})`, "ERROR: `http_request` needs a url"},
+		{`http_request({"url": url, "verb": "GET"})`, "ERROR: unknown option for `http_request`: verb"},
+		{`http_get("http://127.0.0.1:1/")`, "connection refused"},
+		{`http_get(url + "/big")`, "ERROR: http_request: response body larger than 33554432 bytes"},
+	}
+	for _, tt := range tests {
+		env := object.InitContextEnv(ctx)
+		env.Set("url", &object.String{Value: srv.URL})
+
+		evaluated := testEvalEnv(env, tt.input)
+		if !strings.Contains(evaluated.Inspect(), tt.expected) {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+
+	evaluated := testEval(`http_get("http://127.0.0.1:1/")`)
+	testErrorObject(t, evaluated, "`http_get` is not allowed, network access is disabled")
+}
+
+func TestHTTPServe(t *testing.T) {
+	var stderr bytes.Buffer
+	ctx := object.InitContext()
+	ctx.Caps = object.CapNet
+	ctx.Stderr = &stderr
+
+	handler := testEval(`ft(req) {
+		if (req["path"] == "/fail") { return req + 1 }
+		if (req["path"] == "/plain") { return "plain " + req["query"]["q"] }
+		{"status": 201, "headers": {"X-Echo": req["headers"]["X-In"]}, "body": req["method"] + " " + req["body"]}
+	}`)
+
+	srv := httptest.NewServer(httpHandler(ctx, handler))
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		status   int
+		header   string
+		expected string
+	}{
+		{"/echo", 201, "in", "POST data"},
+		{"/plain?q=x", 200, "", "plain x"},
+		{"/fail", 500, "", "type mismatch: HASH + INTEGER\n"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", srv.URL+tt.path, strings.NewReader("data"))
+		req.Header.Set("X-In", "in")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status || resp.Header.Get("X-Echo") != tt.header || string(body) != tt.expected {
+			t.Errorf("wrong response for %s. got=%d %q %q", tt.path, resp.StatusCode, resp.Header.Get("X-Echo"), body)
+		}
+	}
+
+	if stderr.String() != "http_serve: POST /fail: type mismatch: HASH + INTEGER\n" {
+		t.Errorf("handler error not reported. got=%q", stderr.String())
+	}
+
+	resp, err := http.Post(srv.URL+"/echo", "text/plain", bytes.NewReader(make([]byte, maxBodySize+1)))
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body not rejected. got=%d", resp.StatusCode)
+	}
+}
+
+func TestHTTPServeCancel(t *testing.T) {
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := object.InitContext()
+	ctx.Context = cancelCtx
+	ctx.Caps = object.CapNet
+
+	done := make(chan object.Object)
+	go func() {
+		done <- testEvalContext(ctx, `http_serve("127.0.0.1:0", ft(req) { "ok" })`)
+	}()
+
+	cancel()
+	select {
+	case res := <-done:
+		testErrorObject(t, res, "execution cancelled: context canceled")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("http_serve did not stop on cancel")
+	}
+
+	testErrorObject(t, testEval(`http_serve("127.0.0.1:0", puts)`), "`http_serve` is not allowed, network access is disabled")
+
+	ctx = object.InitContext()
+	ctx.Caps = object.CapNet
+	testErrorObject(t, testEvalContext(ctx, `http_serve("127.0.0.1:0", 1)`), "argument to `http_serve` must be FUNCTION or BUILTIN, got INTEGER")
+}
+
+func TestExec(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx := object.InitContext()
+	ctx.Caps = object.CapExec
+	ctx.Env = []string{"GREETING=hi", "PATH=" + os.Getenv("PATH")}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exec("echo", ["a", 1])`, "{stdout: a 1\n, stderr: , code: 0}"},
+		{`exec("sh", ["-c", "echo out; echo err >&2; exit 3"])`, "{stdout: out\n, stderr: err\n, code: 3}"},
+		{`exec("cat", [], {"stdin": "piped"})["stdout"]`, "piped"},
+		{`exec("pwd", [], {"dir": dir})["stdout"]`, dir + "\n"},
+		{`exec("sh", ["-c", "echo $GREETING $EXTRA"])["stdout"]`, "hi\n"},
+		{`exec("sh", ["-c", "echo $GREETING $EXTRA"], {"env": {"EXTRA": "there", "GREETING": "yo"}})["stdout"]`, "yo there\n"},
+		{`exec("sleep", ["5"], {"timeout": 50})`, "ERROR: exec: sleep timed out after 50ms"},
+		{`exec("sleep", ["5"], {"timeout": 0})`, "ERROR: timeout of `exec` must be positive, got 0"},
+		{`exec("no-such-command-here")`, "ERROR: exec: exec: \"no-such-command-here\": executable file not found in $PATH"},
+		{`exec("echo", [], {"shell": true})`, "ERROR: unknown option for `exec`: shell"},
+	}
+	for _, tt := range tests {
+		env := object.InitContextEnv(ctx)
+		env.Set("dir", &object.String{Value: dir})
+
+		evaluated := testEvalEnv(env, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+
+	// the grandchild holds stdout open, the timeout must not wait for it
+	start := time.Now()
+	testErrorObject(t, testEvalContext(ctx, `exec("sh", ["-c", "sleep 3; echo x"], {"timeout": 50})`), "exec: sh timed out after 50ms")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timeout not enforced on a grandchild, took %s", elapsed)
+	}
+
+	testErrorObject(t, testEval(`exec("echo")`), "`exec` is not allowed, running commands is disabled")
+}
+
 func TestStringConcatenationError(t *testing.T) {
 	input := `"Hello" + " " + "World""!"`
 
@@ -238,6 +1090,139 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{`"a" < "b"`, true},
+		{`"abc" < "abd"`, true},
+		{`"ab" < "abc"`, true},
+		{`"b" > "abc"`, true},
+		{`"B" < "a"`, true},
+		{`"a" <= "a"`, true},
+		{`"a" >= "b"`, false},
+	}
+	for _, tt := range tests {
+		testBoolObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringRepeat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"-" * 5`, "-----"},
+		{`3 * "ab"`, "ababab"},
+		{`"x" * 0`, ""},
+		{`"x" * -1`, errorMsg("negative repeat count: -1")},
+		{`"ab" * 9223372036854775807`, errorMsg("repeated string too long: 9223372036854775807 * 2 bytes")},
+		{`"" * 9223372036854775807`, ""},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+		case errorMsg:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestInOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"ell" in "hello"`, true},
+		{`"x" in "hello"`, false},
+		{`"" in "hello"`, true},
+		{`"a" in {"a": 1}`, true},
+		{`"b" in {"a": 1}`, false},
+		{`[1, 2] in {[1, 2]: 1}`, true},
+		{`ft(x) { x } in {"a": 1}`, false},
+		{`2 in [1, 2, 3]`, true},
+		{`[2] in [1, [2], 3]`, true},
+		{`"2" in [1, 2, 3]`, false},
+		{`let xs = [1]; 1 in xs == true`, true},
+		{`1 in "hello"`, errorMsg("unknown operator: INTEGER in STRING")},
+		{`1 in 1`, errorMsg("unknown operator: INTEGER in INTEGER")},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBoolObject(t, evaluated, expected)
+		case errorMsg:
+			testErrorObject(t, evaluated, string(expected))
+		}
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`len("héllo")`, 5},
+		{`len("世界")`, 2},
+		{`"héllo"[1]`, "é"},
+		{`"世界"[1]`, "界"},
+		{`"héllo"[5]`, nil},
+		{`"héllo"[-1]`, errorMsg("index out of bound: -1")},
+		{`"héllo"[1:3]`, "él"},
+		{`"héllo"[:2]`, "hé"},
+		{`"héllo"[3:]`, "lo"},
+		{`"héllo"[2:100]`, "llo"},
+		{`"héllo"[4:2]`, ""},
+		{`let café = "ok"; café`, "ok"},
+		{`len(bytes("é"))`, 2},
+		{`bytes(bytes("héllo"))`, "héllo"},
+		{`runes("é")[0]`, 233},
+		{`runes([19990, 30028])`, "世界"},
+		{`bytes([256])`, errorMsg("argument to `bytes` must hold integers 0-255, got 256")},
+		{`"abc"["a":]`, errorMsg("slice bound must be INTEGER, got STRING")},
+		{`5[1:]`, errorMsg("slice operator is not supported: INTEGER")},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		case errorMsg:
+			testErrorObject(t, evaluated, string(expected))
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestArraySlices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4][2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][3:1]", "[]"},
+		{"[1, 2][0:10]", "[1, 2]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s wrong. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
 func TestStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
 
@@ -345,6 +1330,10 @@ func TestErrorHandling(t *testing.T) {
 			`{"name": "Monkey"}[ft(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			`{[1, ft(x) { x }]: 1}`,
+			"unusable as hash key: ARRAY",
+		},
 		{
 			`"Hello" - "World"`,
 			"unknown operator: STRING - STRING",
@@ -501,6 +1490,74 @@ func TestEvalBoolExpression(t *testing.T) {
 	}
 }
 
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1] == [1]", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, [2, 3]] != [1, [2, 4]]", true},
+		{"[] == []", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`"foo" == "foo"`, true},
+		{`"foo" != "bar"`, true},
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{`[1] == {1: 1}`, false},
+		{"true == 1", false},
+		{"let f = ft(x) { x }; f == f", true},
+		{"ft(x) { x } == ft(x) { x }", false},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBoolObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBigIntPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		objType  object.ObjectType
+	}{
+		{
+			"let fact = ft(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(25)",
+			"15511210043330985984000000",
+			object.BIGINT_OBJ,
+		},
+		{"9223372036854775807 + 1", "9223372036854775808", object.BIGINT_OBJ},
+		{"9223372036854775807 + 1 - 1", "9223372036854775807", object.INTEGER_OBJ},
+		{"-9223372036854775807 - 1 - 1", "-9223372036854775809", object.BIGINT_OBJ},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808", object.BIGINT_OBJ},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808", object.BIGINT_OBJ},
+		{"4294967296 * 4294967296 / 4294967296", "4294967296", object.INTEGER_OBJ},
+		{"9223372036854775807 * 2 > 9223372036854775807", "true", object.BOOL_OBJ},
+		{"9223372036854775807 * 2 == 9223372036854775807 + 9223372036854775807", "true", object.BOOL_OBJ},
+		{"{9223372036854775807 * 2: 1}[9223372036854775807 + 9223372036854775807]", "1", object.INTEGER_OBJ},
+		{"9223372036854775807 * 2 * float(1)", "1.8446744073709552e+19", object.FLOAT_OBJ},
+		{`int("123456789012345678901234567890")`, "123456789012345678901234567890", object.BIGINT_OBJ},
+		{`int("42")`, "42", object.INTEGER_OBJ},
+		{"int(float(7) / float(2))", "3", object.INTEGER_OBJ},
+		{"str(9223372036854775807 * 10)", "92233720368547758070", object.STRING_OBJ},
+		{"float(3)", "3.0", object.FLOAT_OBJ},
+		{`float("2.5")`, "2.5", object.FLOAT_OBJ},
+		{"1 / 0", "ERROR: division by zero", object.ERR_OBJ},
+		{"9223372036854775807 * 2 / 0", "ERROR: division by zero", object.ERR_OBJ},
+		{`int("abc")`, `ERROR: could not parse "abc" as integer`, object.ERR_OBJ},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.objType || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s wrong. got=%s %s, want=%s %s", tt.input,
+				evaluated.Type(), evaluated.Inspect(), tt.objType, tt.expected)
+		}
+	}
+}
+
 func TestEvalIntegerExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -529,6 +1586,16 @@ func TestEvalIntegerExpression(t *testing.T) {
 }
 
 func testEval(input string) object.Object {
+	return testEvalEnv(object.InitEnv(), input)
+}
+
+// testEvalContext evaluates input in a new env over ctx
+func testEvalContext(ctx *object.Context, input string) object.Object {
+	return testEvalEnv(object.InitContextEnv(ctx), input)
+}
+
+// testEvalEnv evaluates input in env, a parser error comes back as an ERROR
+func testEvalEnv(env *object.Environment, input string) object.Object {
 	l := lexer.InitLexer(input)
 	p := parser.InitParser(l)
 	program := p.Parse()
@@ -539,11 +1606,28 @@ func testEval(input string) object.Object {
 		}
 	}
 
-	env := object.InitEnv()
-
 	return Eval(program, env)
 }
 
+// errorMsg marks an expected error message in tests where plain strings
+// are expected STRING values
+type errorMsg string
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+	res, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not String, got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if res.Value != expected {
+		t.Errorf("String has wrong value, got=%q, expected=%q", res.Value, expected)
+		return false
+	}
+
+	return true
+}
+
 func testBoolObject(t *testing.T, obj object.Object, expected bool) bool {
 	res, ok := obj.(*object.Bool)
 	if !ok {
@@ -0,0 +1,150 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(execBuiltins)
+}
+
+// execWaitDelay is how long a cancelled command's output may stay open, by
+// a child it left running, before exec stops waiting for it
+const execWaitDelay = 100 * time.Millisecond
+
+// commandEnv is Context.Env with the "env" option hash laid over it
+func commandEnv(base []string, obj object.Object) ([]string, *object.Error) {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		return nil, newError("env of `exec` must be HASH, got %s", obj.Type())
+	}
+
+	env := make([]string, 0, len(base)+hash.Len())
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := hash.Get(&object.String{Value: key}); !ok {
+			env = append(env, kv)
+		}
+	}
+
+	for _, pair := range hash.Items() {
+		env = append(env, pair.Key.Inspect()+"="+textOf(pair.Val))
+	}
+
+	return env, nil
+}
+
+var execBuiltins = map[string]*object.Builtin{
+	// exec(cmd, args?, opts?) runs cmd without a shell and gives a hash of
+	// stdout, stderr and the exit code, a non-zero code is not an error.
+	// options: "stdin" string, "timeout" in ms or a duration, "dir" and
+	// "env", a hash added to the script's environment. a timeout kills the
+	// command along with the processes it started. needs the exec capability
+	"exec": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if !ctx.Allowed(object.CapExec) {
+				return newError("`exec` is not allowed, running commands is disabled")
+			}
+
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments, got=%d, want 1 to 3", len(args))
+			}
+
+			name, err := stringArg("exec", args[0])
+			if err != nil {
+				return err
+			}
+
+			var cmdArgs []string
+			if len(args) > 1 {
+				elems, errObj := elemsOf(ctx, "exec", args[1])
+				if errObj != nil {
+					return errObj
+				}
+
+				for _, elem := range elems {
+					cmdArgs = append(cmdArgs, textOf(elem))
+				}
+			}
+
+			opts := map[string]object.Object{}
+			if len(args) > 2 {
+				if opts, err = options("exec", args[2], "stdin", "timeout", "dir", "env"); err != nil {
+					return err
+				}
+			}
+
+			runCtx := context.Context(ctx)
+			var timeout time.Duration
+			if t, ok := opts["timeout"]; ok {
+				if timeout, err = durationArg("exec", t); err != nil {
+					return err
+				}
+				if timeout <= 0 {
+					return newError("timeout of `exec` must be positive, got %s", t.Inspect())
+				}
+
+				var cancel context.CancelFunc
+				runCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			cmd := exec.CommandContext(runCtx, name, cmdArgs...)
+			killGroup(cmd)
+			cmd.WaitDelay = execWaitDelay
+			// never nil, that would hand the host environment to the command
+			cmd.Env = append([]string{}, ctx.Env...)
+
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			if in, ok := opts["stdin"]; ok {
+				str, err := stringArg("exec", in)
+				if err != nil {
+					return err
+				}
+				cmd.Stdin = strings.NewReader(str)
+			}
+
+			if dir, ok := opts["dir"]; ok {
+				if cmd.Dir, err = stringArg("exec", dir); err != nil {
+					return err
+				}
+			}
+
+			if env, ok := opts["env"]; ok {
+				if cmd.Env, err = commandEnv(ctx.Env, env); err != nil {
+					return err
+				}
+			}
+
+			runErr := cmd.Run()
+
+			switch {
+			case ctx.Err() != nil:
+				return newError("execution cancelled: %s", ctx.Err())
+			case runCtx.Err() != nil:
+				return newError("exec: %s timed out after %s", name, timeout)
+			}
+
+			var exitErr *exec.ExitError
+			if runErr != nil && !errors.As(runErr, &exitErr) {
+				return newError("exec: %s", runErr)
+			}
+
+			res := object.InitHash()
+			res.Set(&object.String{Value: "stdout"}, &object.String{Value: stdout.String()})
+			res.Set(&object.String{Value: "stderr"}, &object.String{Value: stderr.String()})
+			res.Set(&object.String{Value: "code"}, &object.Integer{Value: int64(cmd.ProcessState.ExitCode())})
+			return res
+		},
+	},
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package evaluator
+
+import "os/exec"
+
+// killGroup keeps the default of killing only the command itself, the
+// WaitDelay of exec still bounds how long its children can hold its output
+func killGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(fileBuiltins)
+}
+
+// openRoot opens the directory the file builtins are confined to, paths
+// leaving it through .. or symlinks fail when used
+func openRoot(ctx *object.Context, name string) (*os.Root, *object.Error) {
+	if !ctx.Allowed(object.CapFiles) {
+		return nil, newError("`%s` is not allowed, file access is disabled", name)
+	}
+
+	dir := ctx.FileRoot
+	if dir == "" {
+		dir = "."
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fileError(name, err)
+	}
+
+	return root, nil
+}
+
+// fileError drops the syscall name from path errors, it differs between systems
+func fileError(name string, err error) *object.Error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return newError("%s: %s: %s", name, pathErr.Path, pathErr.Err)
+	}
+
+	return newError("%s: %s", name, err)
+}
+
+// fileArgs checks the string arguments of a file builtin and opens the root
+func fileArgs(ctx *object.Context, name string, args []object.Object, want int) (*os.Root, []string, *object.Error) {
+	strs, err := stringsArgs(name, args, want)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err := openRoot(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return root, strs, nil
+}
+
+func writeFile(ctx *object.Context, name string, args []object.Object, flag int) object.Object {
+	root, strs, errObj := fileArgs(ctx, name, args, 2)
+	if errObj != nil {
+		return errObj
+	}
+	defer root.Close()
+
+	f, err := root.OpenFile(strs[0], flag|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fileError(name, err)
+	}
+
+	_, err = f.WriteString(strs[1])
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fileError(name, err)
+	}
+
+	return NULL
+}
+
+// file builtins take paths relative to Context.FileRoot and only work when
+// the files capability is granted
+var fileBuiltins = map[string]*object.Builtin{
+	// whole content of a file as a string
+	"read_file": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			root, strs, errObj := fileArgs(ctx, "read_file", args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			defer root.Close()
+
+			b, err := root.ReadFile(strs[0])
+			if err != nil {
+				return fileError("read_file", err)
+			}
+
+			return &object.String{Value: string(b)}
+		},
+	},
+
+	// write_file(path, str) creates or truncates the file
+	"write_file": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return writeFile(ctx, "write_file", args, os.O_TRUNC)
+		},
+	},
+
+	// append_file(path, str) creates the file when missing
+	"append_file": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return writeFile(ctx, "append_file", args, os.O_APPEND)
+		},
+	},
+
+	// sorted names in a directory, directories end with a slash
+	"list_dir": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				args = []object.Object{&object.String{Value: "."}}
+			}
+
+			root, strs, errObj := fileArgs(ctx, "list_dir", args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			defer root.Close()
+
+			entries, err := fs.ReadDir(root.FS(), strs[0])
+			if err != nil {
+				return fileError("list_dir", err)
+			}
+
+			names := make([]string, len(entries))
+			for i, entry := range entries {
+				names[i] = entry.Name()
+				if entry.IsDir() {
+					names[i] += "/"
+				}
+			}
+
+			return stringsToArray(names)
+		},
+	},
+
+	// whether a path exists, escaping the root is still an error
+	"exists": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			root, strs, errObj := fileArgs(ctx, "exists", args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			defer root.Close()
+
+			_, err := root.Stat(strs[0])
+			if errors.Is(err, fs.ErrNotExist) {
+				return FALSE
+			}
+
+			if err != nil {
+				return fileError("exists", err)
+			}
+
+			return TRUE
+		},
+	},
+
+	// remove a file or an empty directory
+	"remove": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			root, strs, errObj := fileArgs(ctx, "remove", args, 1)
+			if errObj != nil {
+				return errObj
+			}
+			defer root.Close()
+
+			if err := root.Remove(strs[0]); err != nil {
+				return fileError("remove", err)
+			}
+
+			return NULL
+		},
+	},
+}
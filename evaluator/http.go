@@ -0,0 +1,319 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(httpBuiltins)
+}
+
+const (
+	// maxBodySize bounds the request and response bodies read into a string
+	maxBodySize = 32 << 20
+
+	// time http_serve gives a client to send the headers, and the whole request
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = time.Minute
+)
+
+func checkNet(ctx *object.Context, name string) *object.Error {
+	if !ctx.Allowed(object.CapNet) {
+		return newError("`%s` is not allowed, network access is disabled", name)
+	}
+
+	return nil
+}
+
+// headersHash turns headers into a hash sorted by name, repeated values
+// are joined with a comma
+func headersHash(h http.Header) *object.Hash {
+	hash := object.InitHash()
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		hash.Set(&object.String{Value: name}, &object.String{Value: strings.Join(h[name], ", ")})
+	}
+
+	return hash
+}
+
+// setHeaders copies a hash of headers onto h
+func setHeaders(name string, h http.Header, obj object.Object) *object.Error {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		return newError("headers of `%s` must be HASH, got %s", name, obj.Type())
+	}
+
+	for _, pair := range hash.Items() {
+		h.Set(pair.Key.Inspect(), pair.Val.Inspect())
+	}
+
+	return nil
+}
+
+func httpRequest(ctx *object.Context, opts map[string]object.Object) object.Object {
+	method := "GET"
+	if m, ok := opts["method"]; ok {
+		str, err := stringArg("http_request", m)
+		if err != nil {
+			return err
+		}
+		method = strings.ToUpper(str)
+	}
+
+	rawURL, ok := opts["url"]
+	if !ok {
+		return newError("`http_request` needs a url")
+	}
+
+	url, errObj := stringArg("http_request", rawURL)
+	if errObj != nil {
+		return errObj
+	}
+
+	var body io.Reader
+	if b, ok := opts["body"]; ok {
+		str, err := stringArg("http_request", b)
+		if err != nil {
+			return err
+		}
+		body = strings.NewReader(str)
+	}
+
+	reqCtx := context.Context(ctx)
+	if t, ok := opts["timeout"]; ok {
+		d, err := durationArg("http_request", t)
+		if err != nil {
+			return err
+		}
+
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, method, url, body)
+	if err != nil {
+		return newError("http_request: %s", err)
+	}
+
+	if h, ok := opts["headers"]; ok {
+		if err := setHeaders("http_request", req.Header, h); err != nil {
+			return err
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return newError("http_request: %s", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return newError("http_request: %s", err)
+	}
+
+	if len(b) > maxBodySize {
+		return newError("http_request: response body larger than %d bytes", maxBodySize)
+	}
+
+	res := object.InitHash()
+	res.Set(&object.String{Value: "status"}, &object.Integer{Value: int64(resp.StatusCode)})
+	res.Set(&object.String{Value: "headers"}, headersHash(resp.Header))
+	res.Set(&object.String{Value: "body"}, &object.String{Value: string(b)})
+	return res
+}
+
+// requestHash is what a handler of http_serve is called with, r.Body
+// must already be limited to maxBodySize
+func requestHash(r *http.Request) (*object.Hash, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	query := object.InitHash()
+	values := r.URL.Query()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		query.Set(&object.String{Value: key}, &object.String{Value: values.Get(key)})
+	}
+
+	req := object.InitHash()
+	req.Set(&object.String{Value: "method"}, &object.String{Value: r.Method})
+	req.Set(&object.String{Value: "path"}, &object.String{Value: r.URL.Path})
+	req.Set(&object.String{Value: "query"}, query)
+	req.Set(&object.String{Value: "headers"}, headersHash(r.Header))
+	req.Set(&object.String{Value: "body"}, &object.String{Value: string(b)})
+	return req, nil
+}
+
+// writeResponse writes what a handler returned, a hash with status, headers
+// and body or any other value as a 200 body
+func writeResponse(w http.ResponseWriter, res object.Object) {
+	hash, ok := res.(*object.Hash)
+	if !ok {
+		io.WriteString(w, textOf(res))
+		return
+	}
+
+	status := http.StatusOK
+	if pair, ok := hash.Get(&object.String{Value: "status"}); ok {
+		n, ok := pair.Val.(*object.Integer)
+		if !ok || n.Value < 100 || n.Value > 999 {
+			http.Error(w, fmt.Sprintf("invalid status: %s", pair.Val.Inspect()), http.StatusInternalServerError)
+			return
+		}
+		status = int(n.Value)
+	}
+
+	if pair, ok := hash.Get(&object.String{Value: "headers"}); ok {
+		if err := setHeaders("http_serve", w.Header(), pair.Val); err != nil {
+			http.Error(w, err.Message, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(status)
+	if pair, ok := hash.Get(&object.String{Value: "body"}); ok {
+		io.WriteString(w, textOf(pair.Val))
+	}
+}
+
+// httpHandler calls fn for every request, each on a fork of ctx. an error
+// in fn is a 500 and is written to Stderr
+func httpHandler(ctx *object.Context, fn object.Object) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+		req, err := requestHash(r)
+		if err != nil {
+			status := http.StatusBadRequest
+			if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		res := applyFunc(ctx.Fork(), fn, []object.Object{req})
+		if errObj, ok := res.(*object.Error); ok {
+			fmt.Fprintf(ctx.Stderr, "http_serve: %s %s: %s\n", r.Method, r.URL.Path, errObj.Message)
+			http.Error(w, errObj.Message, http.StatusInternalServerError)
+			return
+		}
+
+		writeResponse(w, res)
+	})
+}
+
+// the http builtins need the net capability
+var httpBuiltins = map[string]*object.Builtin{
+	// http_get(url) is http_request with just a url
+	"http_get": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkNet(ctx, "http_get"); err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			return httpRequest(ctx, map[string]object.Object{"url": args[0]})
+		},
+	},
+
+	// http_request(opts) with "url" and the optional "method", "headers",
+	// "body" and "timeout". gives a hash of status, headers and body, a
+	// status that is not 2xx is not an error
+	"http_request": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkNet(ctx, "http_request"); err != nil {
+				return err
+			}
+
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			opts, err := options("http_request", args[0], "url", "method", "headers", "body", "timeout")
+			if err != nil {
+				return err
+			}
+
+			return httpRequest(ctx, opts)
+		},
+	},
+
+	// http_serve(addr, handler) serves until the run is cancelled. handler
+	// gets a hash of method, path, query, headers and body and returns a
+	// hash of status, headers and body, or just the body
+	"http_serve": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if err := checkNet(ctx, "http_serve"); err != nil {
+				return err
+			}
+
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			addr, err := stringArg("http_serve", args[0])
+			if err != nil {
+				return err
+			}
+
+			switch args[1].(type) {
+			case *object.Function, *object.Builtin:
+			default:
+				return newError("argument to `http_serve` must be FUNCTION or BUILTIN, got %s", args[1].Type())
+			}
+
+			ln, listenErr := net.Listen("tcp", addr)
+			if listenErr != nil {
+				return newError("http_serve: %s", listenErr)
+			}
+
+			srv := &http.Server{
+				Handler:           httpHandler(ctx, args[1]),
+				ReadHeaderTimeout: readHeaderTimeout,
+				ReadTimeout:       readTimeout,
+			}
+			served := make(chan error, 1)
+			go func() { served <- srv.Serve(ln) }()
+
+			select {
+			case serveErr := <-served:
+				return newError("http_serve: %s", serveErr)
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				srv.Shutdown(shutdownCtx)
+				return newError("execution cancelled: %s", ctx.Err())
+			}
+		},
+	},
+}
@@ -0,0 +1,76 @@
+package evaluator
+
+import "github.com/Aergiaaa/simplescript/object"
+
+func init() {
+	register(inputBuiltins)
+}
+
+// every input builtin reads through the same buffer over Context.Stdin, so
+// they can be mixed freely
+var inputBuiltins = map[string]*object.Builtin{
+	// next line of stdin without its line ending, null at the end of input
+	"read_line": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments, got=%d, want=0", len(args))
+			}
+
+			line, ok, err := ctx.ReadLine()
+			if err != nil {
+				return newError("read_line: %s", err)
+			}
+
+			if !ok {
+				return NULL
+			}
+
+			return &object.String{Value: line}
+		},
+	},
+
+	// the rest of stdin
+	"read_all": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments, got=%d, want=0", len(args))
+			}
+
+			all, err := ctx.ReadAll()
+			if err != nil {
+				return newError("read_all: %s", err)
+			}
+
+			return &object.String{Value: all}
+		},
+	},
+
+	// lazy iterator over the remaining lines of stdin
+	"lines": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments, got=%d, want=0", len(args))
+			}
+
+			done := false
+			return object.InitIter(func() (object.Object, bool) {
+				if done {
+					return nil, false
+				}
+
+				line, ok, err := ctx.ReadLine()
+				if err != nil {
+					done = true
+					return newError("lines: %s", err), true
+				}
+
+				if !ok {
+					done = true
+					return nil, false
+				}
+
+				return &object.String{Value: line}, true
+			})
+		},
+	},
+}
@@ -0,0 +1,327 @@
+package evaluator
+
+import (
+	"github.com/Aergiaaa/simplescript/ast"
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(iterBuiltins)
+}
+
+// newGenerator binds args like a normal call but only runs the body as
+// values are pulled out of the returned generator
+func newGenerator(ctx *object.Context, fn *object.Function, args []object.Object) object.Object {
+	env := extFuncEnv(ctx, fn, args)
+
+	return object.InitGenerator(func(yield func(object.Object) bool) {
+		stopped := false
+		env.SetYield(func(val object.Object) bool {
+			stopped = !yield(val)
+			return !stopped
+		})
+
+		res := Eval(fn.Body, env)
+		if isError(res) && !stopped {
+			yield(res)
+		}
+	})
+}
+
+func evalYieldExpr(node *ast.YieldExpression, env *object.Environment) object.Object {
+	var val object.Object = NULL
+	if node.Value != nil {
+		val = Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+	}
+
+	yield, ok := env.Yield()
+	if !ok {
+		return newError("yield outside generator")
+	}
+
+	if !yield(val) {
+		return newError("generator stopped")
+	}
+
+	return NULL
+}
+
+// iterate returns an iterator over obj, ok is false when obj is not iterable.
+// a channel is received from until ctx is cancelled, which ends the
+// iteration with an error.
+func iterate(ctx *object.Context, obj object.Object) (object.Iterator, bool) {
+	if ch, ok := obj.(*object.Channel); ok {
+		return object.InitIter(func() (object.Object, bool) {
+			val, ok, err := ch.Recv(ctx)
+			if err != nil {
+				return newError("execution cancelled: %s", err), true
+			}
+			return val, ok
+		}), true
+	}
+
+	iterable, ok := obj.(object.Iterable)
+	if !ok {
+		return nil, false
+	}
+
+	return iterable.Iter(), true
+}
+
+// isLazy report whether obj produces its values on demand. lazy inputs give
+// lazy results in map, filter, take and zip, collections give arrays.
+func isLazy(obj object.Object) bool {
+	switch obj.(type) {
+	case object.Iterator, *object.Range, *object.Channel:
+		return true
+	default:
+		return false
+	}
+}
+
+// collect drains it into an array, stopping at the first error
+func collect(it object.Iterator) object.Object {
+	elems := []object.Object{}
+	for {
+		val, ok := it.Next()
+		if !ok {
+			return &object.Array{Elems: elems}
+		}
+
+		if isError(val) {
+			return val
+		}
+
+		elems = append(elems, val)
+	}
+}
+
+// result hands back it as is for lazy input, or drained otherwise
+func result(src object.Object, it object.Iterator) object.Object {
+	if isLazy(src) {
+		return it
+	}
+
+	return collect(it)
+}
+
+var iterBuiltins = map[string]*object.Builtin{
+	// iterator over any iterable
+	"iter": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			it, ok := iterate(ctx, args[0])
+			if !ok {
+				return newError("argument to `iter` must be iterable, got %s", args[0].Type())
+			}
+
+			return it
+		},
+	},
+
+	// next value of an iterator, or the second argument (default null) once exhausted
+	"next": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			it, ok := args[0].(object.Iterator)
+			if !ok {
+				return newError("argument to `next` must be ITERATOR or GENERATOR, got %s", args[0].Type())
+			}
+
+			val, ok := it.Next()
+			if !ok {
+				if len(args) == 2 {
+					return args[1]
+				}
+				return NULL
+			}
+
+			return val
+		},
+	},
+
+	// every remaining value of an iterable as an array
+	"collect": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			it, ok := iterate(ctx, args[0])
+			if !ok {
+				return newError("argument to `collect` must be iterable, got %s", args[0].Type())
+			}
+
+			return collect(it)
+		},
+	},
+
+	// range(stop), range(start, stop) or range(start, stop, step)
+	"range": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments, got=%d, want 1 to 3", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument to `range` must be INTEGER, got %s", arg.Type())
+				}
+				bounds[i] = n.Value
+			}
+
+			r := &object.Range{Step: 1}
+			switch len(bounds) {
+			case 1:
+				r.Stop = bounds[0]
+			case 2:
+				r.Start, r.Stop = bounds[0], bounds[1]
+			case 3:
+				r.Start, r.Stop, r.Step = bounds[0], bounds[1], bounds[2]
+			}
+
+			if r.Step == 0 {
+				return newError("range step must not be zero")
+			}
+
+			return r
+		},
+	},
+
+	// apply f to every value
+	"map": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			src, f := args[0], args[1]
+			it, ok := iterate(ctx, src)
+			if !ok {
+				return newError("argument to `map` must be iterable, got %s", src.Type())
+			}
+
+			return result(src, object.InitIter(func() (object.Object, bool) {
+				val, ok := it.Next()
+				if !ok || isError(val) {
+					return val, ok
+				}
+
+				return applyFunc(ctx, f, []object.Object{val}), true
+			}))
+		},
+	},
+
+	// keep the values f returns a truthy value for
+	"filter": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			src, f := args[0], args[1]
+			it, ok := iterate(ctx, src)
+			if !ok {
+				return newError("argument to `filter` must be iterable, got %s", src.Type())
+			}
+
+			return result(src, object.InitIter(func() (object.Object, bool) {
+				for {
+					val, ok := it.Next()
+					if !ok || isError(val) {
+						return val, ok
+					}
+
+					keep := applyFunc(ctx, f, []object.Object{val})
+					if isError(keep) {
+						return keep, true
+					}
+
+					if isTruthy(keep) {
+						return val, true
+					}
+				}
+			}))
+		},
+	},
+
+	// the first n values
+	"take": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			src := args[0]
+			it, ok := iterate(ctx, src)
+			if !ok {
+				return newError("argument to `take` must be iterable, got %s", src.Type())
+			}
+
+			n, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument to `take` must be INTEGER, got %s", args[1].Type())
+			}
+
+			left := n.Value
+			return result(src, object.InitIter(func() (object.Object, bool) {
+				if left <= 0 {
+					return nil, false
+				}
+
+				left--
+				return it.Next()
+			}))
+		},
+	},
+
+	// arrays of the n-th values of every argument, stops with the shortest
+	"zip": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments, got=%d, want at least 2", len(args))
+			}
+
+			lazy := false
+			its := make([]object.Iterator, len(args))
+			for i, arg := range args {
+				it, ok := iterate(ctx, arg)
+				if !ok {
+					return newError("argument to `zip` must be iterable, got %s", arg.Type())
+				}
+				its[i] = it
+				lazy = lazy || isLazy(arg)
+			}
+
+			zipped := object.InitIter(func() (object.Object, bool) {
+				elems := make([]object.Object, len(its))
+				for i, it := range its {
+					val, ok := it.Next()
+					if !ok || isError(val) {
+						return val, ok
+					}
+					elems[i] = val
+				}
+
+				return &object.Array{Elems: elems}, true
+			})
+
+			if lazy {
+				return zipped
+			}
+
+			return collect(zipped)
+		},
+	},
+}
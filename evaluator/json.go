@@ -0,0 +1,215 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(jsonBuiltins)
+}
+
+// jsonDecode reads one value from the token stream, objects become hashes
+// keeping the key order of the input
+func jsonDecode(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			arr := &object.Array{Elems: []object.Object{}}
+			for dec.More() {
+				val, err := jsonDecode(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr.Elems = append(arr.Elems, val)
+			}
+			_, err := dec.Token()
+			return arr, err
+		case '{':
+			hash := object.InitHash()
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				val, err := jsonDecode(dec)
+				if err != nil {
+					return nil, err
+				}
+				hash.Set(&object.String{Value: key.(string)}, val)
+			}
+			_, err := dec.Token()
+			return hash, err
+		}
+	case json.Number:
+		if i, ok := new(big.Int).SetString(tok.String(), 10); ok {
+			return object.IntegerFromBig(i), nil
+		}
+
+		f, err := tok.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &object.Float{Value: f}, nil
+	case string:
+		return &object.String{Value: tok}, nil
+	case bool:
+		return nativeBoolToBoolObj(tok), nil
+	case nil:
+		return NULL, nil
+	}
+
+	return nil, errors.New("unexpected token")
+}
+
+func jsonParse(src string) object.Object {
+	dec := json.NewDecoder(strings.NewReader(src))
+	dec.UseNumber()
+
+	val, err := jsonDecode(dec)
+	offset := dec.InputOffset()
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return val
+		} else if err == nil {
+			err = errors.New("extra data after value")
+			offset += int64(len(src[offset:]) - len(strings.TrimLeft(src[offset:], " \t\r\n")))
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+	if err == io.EOF {
+		err = errors.New("unexpected end of JSON input")
+	}
+
+	return newError("invalid json at offset %d: %s", offset, err)
+}
+
+// jsonEncode writes obj to buf as compact json, hash keys that are not
+// strings are written as they print. seen holds the arrays and hashes being
+// encoded so a cyclic one is an error
+func jsonEncode(buf *bytes.Buffer, obj object.Object, seen map[object.Object]bool) *object.Error {
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		if seen[obj] {
+			return newError("cannot encode cyclic %s as json", obj.Type())
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Null:
+		buf.WriteString("null")
+	case *object.Bool, *object.Integer, *object.BigInt:
+		buf.WriteString(obj.Inspect())
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return newError("cannot encode %s as json", obj.Inspect())
+		}
+		buf.WriteString(obj.Inspect())
+	case *object.String:
+		b, _ := json.Marshal(obj.Value)
+		buf.Write(b)
+	case *object.Array:
+		buf.WriteByte('[')
+		for i, elem := range obj.Elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := jsonEncode(buf, elem, seen); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *object.Hash:
+		buf.WriteByte('{')
+		for i, pair := range obj.Items() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			switch pair.Key.(type) {
+			case *object.String, *object.Integer, *object.BigInt, *object.Bool:
+				b, _ := json.Marshal(pair.Key.Inspect())
+				buf.Write(b)
+			default:
+				return newError("cannot encode %s hash key as json", pair.Key.Type())
+			}
+
+			buf.WriteByte(':')
+			if err := jsonEncode(buf, pair.Val, seen); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return newError("cannot encode %s as json", obj.Type())
+	}
+
+	return nil
+}
+
+var jsonBuiltins = map[string]*object.Builtin{
+	// json_parse(str) turns json into hashes, arrays, strings, numbers, bools
+	// and null. whole numbers become INTEGER (or BIGINT), the rest FLOAT
+	"json_parse": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("json_parse", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return jsonParse(strs[0])
+		},
+	},
+
+	// json_stringify(value, indent?) with indent a number of spaces or a string
+	"json_stringify": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			var buf bytes.Buffer
+			if err := jsonEncode(&buf, args[0], map[object.Object]bool{}); err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				return &object.String{Value: buf.String()}
+			}
+
+			var indent string
+			switch arg := args[1].(type) {
+			case *object.Integer:
+				indent = strings.Repeat(" ", int(max(min(arg.Value, 10), 0)))
+			case *object.String:
+				indent = arg.Value
+			default:
+				return newError("argument to `json_stringify` must be INTEGER or STRING, got %s", arg.Type())
+			}
+
+			var out bytes.Buffer
+			json.Indent(&out, buf.Bytes(), "", indent)
+			return &object.String{Value: out.String()}
+		},
+	},
+}
@@ -0,0 +1,335 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(mathBuiltins)
+}
+
+// constants resolve after the builtins, a `let` can shadow them
+var constants = map[string]object.Object{
+	"PI": &object.Float{Value: math.Pi},
+	"E":  &object.Float{Value: math.E},
+}
+
+func numberArg(name string, arg object.Object) (float64, *object.Error) {
+	if !isNumber(arg.Type()) {
+		return 0, newError("argument to `%s` must be a number, got %s", name, arg.Type())
+	}
+
+	return toFloat(arg), nil
+}
+
+// floatFunc wraps a float64 function of one argument
+func floatFunc(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			x, err := numberArg(name, args[0])
+			if err != nil {
+				return err
+			}
+
+			return &object.Float{Value: fn(x)}
+		},
+	}
+}
+
+// roundFunc wraps floor, ceil and round, integers are returned as they are
+// and floats become integers
+func roundFunc(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				f := fn(arg.Value)
+				if math.IsNaN(f) || math.IsInf(f, 0) {
+					return newError("cannot convert %s to integer", arg.Inspect())
+				}
+				i, _ := big.NewFloat(f).Int(nil)
+				return object.IntegerFromBig(i)
+			default:
+				return newError("argument to `%s` must be a number, got %s", name, arg.Type())
+			}
+		},
+	}
+}
+
+// extreme returns the smallest (sign -1) or largest (sign 1) of the
+// arguments, or of the values of a single iterable argument. a single
+// argument that is not iterable is returned as is
+func extreme(ctx *object.Context, name string, sign int, args []object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments, got=0, want at least 1")
+	}
+
+	if len(args) == 1 {
+		if _, ok := iterate(ctx, args[0]); !ok {
+			return args[0]
+		}
+
+		elems, err := elemsOf(ctx, name, args[0])
+		if err != nil {
+			return err
+		}
+		if len(elems) == 0 {
+			return newError("argument to `%s` must not be empty", name)
+		}
+		args = elems
+	}
+
+	best := args[0]
+	for _, arg := range args[1:] {
+		c, err := compareObjects(arg, best)
+		if err != nil {
+			return err
+		}
+
+		if c == sign {
+			best = arg
+		}
+	}
+
+	return best
+}
+
+var mathBuiltins = map[string]*object.Builtin{
+	// absolute value, keeps the kind of number
+	"abs": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				if arg.Value >= 0 {
+					return arg
+				}
+				return evalNegOpExpr(arg)
+			case *object.BigInt:
+				return object.IntegerFromBig(new(big.Int).Abs(arg.Value))
+			case *object.Float:
+				return &object.Float{Value: math.Abs(arg.Value)}
+			default:
+				return newError("argument to `abs` must be a number, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// min(a, b, ...) or min(iterable), anything `<` orders
+	"min": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return extreme(ctx, "min", -1, args)
+		},
+	},
+
+	// max(a, b, ...) or max(iterable), anything `<` orders
+	"max": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return extreme(ctx, "max", 1, args)
+		},
+	},
+
+	// clamp(x, lo, hi) limits x to [lo, hi]
+	"clamp": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments, got=%d, want=3", len(args))
+			}
+
+			x, lo, hi := args[0], args[1], args[2]
+			if c, err := compareObjects(lo, hi); err != nil {
+				return err
+			} else if c > 0 {
+				return newError("`clamp` bounds out of order: %s > %s", lo.Inspect(), hi.Inspect())
+			}
+
+			if c, err := compareObjects(x, lo); err != nil {
+				return err
+			} else if c < 0 {
+				return lo
+			}
+
+			if c, err := compareObjects(x, hi); err != nil {
+				return err
+			} else if c > 0 {
+				return hi
+			}
+
+			return x
+		},
+	},
+
+	// pow(x, y), exact for integers with y >= 0, FLOAT otherwise
+	"pow": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			x, err := numberArg("pow", args[0])
+			if err != nil {
+				return err
+			}
+
+			y, err := numberArg("pow", args[1])
+			if err != nil {
+				return err
+			}
+
+			if isInteger(args[0].Type()) && isInteger(args[1].Type()) && y >= 0 {
+				if y > 1<<16 {
+					return newError("exponent too large for `pow`: %s", args[1].Inspect())
+				}
+				return object.IntegerFromBig(new(big.Int).Exp(toBig(args[0]), toBig(args[1]), nil))
+			}
+
+			return &object.Float{Value: math.Pow(x, y)}
+		},
+	},
+
+	"sqrt": floatFunc("sqrt", math.Sqrt),
+	"exp":  floatFunc("exp", math.Exp),
+	"sin":  floatFunc("sin", math.Sin),
+	"cos":  floatFunc("cos", math.Cos),
+	"tan":  floatFunc("tan", math.Tan),
+	"asin": floatFunc("asin", math.Asin),
+	"acos": floatFunc("acos", math.Acos),
+	"atan": floatFunc("atan", math.Atan),
+
+	"log2":  floatFunc("log2", math.Log2),
+	"log10": floatFunc("log10", math.Log10),
+
+	// log(x) is the natural logarithm, log(x, base) any other
+	"log": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			x, err := numberArg("log", args[0])
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				return &object.Float{Value: math.Log(x)}
+			}
+
+			base, err := numberArg("log", args[1])
+			if err != nil {
+				return err
+			}
+
+			return &object.Float{Value: math.Log(x) / math.Log(base)}
+		},
+	},
+
+	// atan2(y, x) is the angle of the point (x, y)
+	"atan2": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			y, err := numberArg("atan2", args[0])
+			if err != nil {
+				return err
+			}
+
+			x, err := numberArg("atan2", args[1])
+			if err != nil {
+				return err
+			}
+
+			return &object.Float{Value: math.Atan2(y, x)}
+		},
+	},
+
+	"floor": roundFunc("floor", math.Floor),
+	"ceil":  roundFunc("ceil", math.Ceil),
+
+	// rounds half away from zero
+	"round": roundFunc("round", math.Round),
+
+	// random() is a FLOAT in [0, 1), random(n) an INTEGER in [0, n) and
+	// random(lo, hi) an INTEGER in [lo, hi]
+	"random": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) > 2 {
+				return newError("wrong number of arguments, got=%d, want 0 to 2", len(args))
+			}
+
+			if len(args) == 0 {
+				return &object.Float{Value: ctx.Rand.Float64()}
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				n, err := intArg("random", arg)
+				if err != nil {
+					return err
+				}
+				bounds[i] = n
+			}
+
+			lo, hi := int64(0), int64(0)
+			if len(bounds) == 1 {
+				if bounds[0] <= 0 {
+					last := new(big.Int).Sub(big.NewInt(bounds[0]), big.NewInt(1))
+					return newError("empty range for `random`: 0 to %s", last)
+				}
+				hi = bounds[0] - 1
+			} else {
+				lo, hi = bounds[0], bounds[1]
+			}
+
+			if hi < lo {
+				return newError("empty range for `random`: %d to %d", lo, hi)
+			}
+
+			// the span of the whole int64 range only fits in a uint64
+			span := uint64(hi) - uint64(lo)
+			var offset uint64
+			if span == math.MaxUint64 {
+				offset = ctx.Rand.Uint64()
+			} else {
+				offset = ctx.Rand.Uint64N(span + 1)
+			}
+
+			return &object.Integer{Value: int64(uint64(lo) + offset)}
+		},
+	},
+
+	// seed(n) makes the following `random` values repeat for the same n
+	"seed": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			n, err := intArg("seed", args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx.Rand.Seed(uint64(n))
+			return NULL
+		},
+	},
+}
@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"slices"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(mutateBuiltins)
+}
+
+// builtins ending in ! change their first argument in place, see
+// object.Array for the value semantics
+var mutateBuiltins = map[string]*object.Builtin{
+	// append values to the end of an array, returns the array
+	"append!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) < 2 {
+				return newError("wrong number of arguments, got=%d, want at least 2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `append!` must be ARRAY, got %s", args[0].Type())
+			}
+
+			arr.Elems = append(arr.Elems, args[1:]...)
+			return arr
+		},
+	},
+
+	// remove and return the last value of an array, null when empty
+	"pop!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `pop!` must be ARRAY, got %s", args[0].Type())
+			}
+
+			n := len(arr.Elems)
+			if n == 0 {
+				return NULL
+			}
+
+			last := arr.Elems[n-1]
+			arr.Elems[n-1] = nil
+			arr.Elems = arr.Elems[:n-1]
+			return last
+		},
+	},
+
+	// insert a value before index i, i may equal the length. returns the array
+	"insert!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments, got=%d, want=3", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `insert!` must be ARRAY, got %s", args[0].Type())
+			}
+
+			i, err := mutateIndex("insert!", args[1], len(arr.Elems)+1)
+			if err != nil {
+				return err
+			}
+
+			arr.Elems = slices.Insert(arr.Elems, i, args[2])
+			return arr
+		},
+	},
+
+	// remove and return the value at index i
+	"remove!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `remove!` must be ARRAY, got %s", args[0].Type())
+			}
+
+			i, err := mutateIndex("remove!", args[1], len(arr.Elems))
+			if err != nil {
+				return err
+			}
+
+			val := arr.Elems[i]
+			arr.Elems = slices.Delete(arr.Elems, i, i+1)
+			return val
+		},
+	},
+
+	// set!(hash, key, val) stores a pair, set!(array, i, val) replaces an
+	// element. returns the collection
+	"set!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments, got=%d, want=3", len(args))
+			}
+
+			switch coll := args[0].(type) {
+			case *object.Hash:
+				if !coll.Set(args[1], args[2]) {
+					return newError("unusable as hash key: %s", args[1].Type())
+				}
+				return coll
+			case *object.Array:
+				i, err := mutateIndex("set!", args[1], len(coll.Elems))
+				if err != nil {
+					return err
+				}
+				coll.Elems[i] = args[2]
+				return coll
+			default:
+				return newError("argument to `set!` must be HASH or ARRAY, got %s", args[0].Type())
+			}
+		},
+	},
+
+	// remove a key from a hash, returns its value or null when it was missing
+	"delete!": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `delete!` must be HASH, got %s", args[0].Type())
+			}
+
+			if _, ok := object.HashKeyOf(args[1]); !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			pair, ok := hash.Delete(args[1])
+			if !ok {
+				return NULL
+			}
+
+			return pair.Val
+		},
+	},
+}
+
+// mutateIndex checks idx is an INTEGER in [0, n)
+func mutateIndex(name string, idx object.Object, n int) (int, *object.Error) {
+	i, ok := idx.(*object.Integer)
+	if !ok {
+		return 0, newError("argument to `%s` must be INTEGER, got %s", name, idx.Type())
+	}
+
+	if i.Value < 0 || i.Value >= int64(n) {
+		return 0, newError("index out of bound: %d", i.Value)
+	}
+
+	return int(i.Value), nil
+}
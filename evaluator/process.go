@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(processBuiltins)
+}
+
+var processBuiltins = map[string]*object.Builtin{
+	// the arguments given to the script
+	"args": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments, got=%d, want=0", len(args))
+			}
+
+			return stringsToArray(ctx.Args)
+		},
+	},
+
+	// env() is a hash of every variable, env(name) one value or null when unset
+	"env": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got=%d, want 0 or 1", len(args))
+			}
+
+			if len(args) == 0 {
+				hash := object.InitHash()
+				for _, kv := range ctx.Env {
+					key, val, _ := strings.Cut(kv, "=")
+					hash.Set(&object.String{Value: key}, &object.String{Value: val})
+				}
+				return hash
+			}
+
+			name, err := stringArg("env", args[0])
+			if err != nil {
+				return err
+			}
+
+			// the last entry wins, like os.Getenv on a duplicated variable
+			var found object.Object = NULL
+			for _, kv := range ctx.Env {
+				if key, val, _ := strings.Cut(kv, "="); key == name {
+					found = &object.String{Value: val}
+				}
+			}
+
+			return found
+		},
+	},
+
+	// exit(code?) stops the script, unwinding every call like an error.
+	// the default code is 0, codes go from 0 to 255
+	"exit": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got=%d, want 0 or 1", len(args))
+			}
+
+			code := int64(0)
+			if len(args) == 1 {
+				var err *object.Error
+				if code, err = intArg("exit", args[0]); err != nil {
+					return err
+				}
+				if code < 0 || code > 255 {
+					return newError("argument to `exit` must be an INTEGER from 0 to 255, got %d", code)
+				}
+			}
+
+			return &object.Error{Message: fmt.Sprintf("exit status %d", code), Exit: true, Code: int(code)}
+		},
+	},
+}
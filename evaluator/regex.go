@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"regexp"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(regexBuiltins)
+}
+
+// regexArg accepts a REGEX or a STRING pattern, compiled through the cache
+func regexArg(name string, arg object.Object) (*regexp.Regexp, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Regex:
+		return arg.Value, nil
+	case *object.String:
+		r, err := object.CompileRegex(arg.Value)
+		if err != nil {
+			return nil, newError("invalid regex: %s", err)
+		}
+		return r.Value, nil
+	default:
+		return nil, newError("argument to `%s` must be REGEX or STRING, got %s", name, arg.Type())
+	}
+}
+
+// regexArgs checks the usual (pattern, str) arguments
+func regexArgs(name string, args []object.Object, want int) (*regexp.Regexp, string, *object.Error) {
+	if len(args) != want {
+		return nil, "", newError("wrong number of arguments, got=%d, want=%d", len(args), want)
+	}
+
+	r, err := regexArg(name, args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	str, err := stringArg(name, args[1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return r, str, nil
+}
+
+// regexReplace is `replace` with a REGEX, with is a template where $1 or
+// ${name} expand to groups, or a function called with the matched text
+func regexReplace(ctx *object.Context, r *regexp.Regexp, str string, with object.Object) object.Object {
+	switch with := with.(type) {
+	case *object.String:
+		return &object.String{Value: r.ReplaceAllString(str, with.Value)}
+	case *object.Function, *object.Builtin:
+		var errObj object.Object
+		res := r.ReplaceAllStringFunc(str, func(match string) string {
+			if errObj != nil {
+				return match
+			}
+
+			val := applyFunc(ctx, with, []object.Object{&object.String{Value: match}})
+			if isError(val) {
+				errObj = val
+				return match
+			}
+
+			return val.Inspect()
+		})
+
+		if errObj != nil {
+			return errObj
+		}
+
+		return &object.String{Value: res}
+	default:
+		return newError("argument to `replace` must be STRING or FUNCTION, got %s", with.Type())
+	}
+}
+
+func groupValue(str string, loc []int, i int) object.Object {
+	if loc[2*i] < 0 {
+		return NULL
+	}
+
+	return &object.String{Value: str[loc[2*i]:loc[2*i+1]]}
+}
+
+var regexBuiltins = map[string]*object.Builtin{
+	// compile a pattern in Go's regexp syntax
+	"re": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("re", args, 1)
+			if err != nil {
+				return err
+			}
+
+			r, compileErr := object.CompileRegex(strs[0])
+			if compileErr != nil {
+				return newError("invalid regex: %s", compileErr)
+			}
+
+			return r
+		},
+	},
+
+	// match(re, str) reports whether re matches anywhere in str
+	"match": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			r, str, err := regexArgs("match", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(r.MatchString(str))
+		},
+	},
+
+	// find_all(re, str) is every matched text, left to right
+	"find_all": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			r, str, err := regexArgs("find_all", args, 2)
+			if err != nil {
+				return err
+			}
+
+			matches := r.FindAllString(str, -1)
+			if matches == nil {
+				matches = []string{}
+			}
+
+			return stringsToArray(matches)
+		},
+	},
+
+	// captures(re, str) are the groups of the first match, null when there
+	// is none. a hash of the named groups when the pattern has any, an
+	// array of the whole match and every group otherwise. groups that did
+	// not take part in the match are null
+	"captures": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			r, str, err := regexArgs("captures", args, 2)
+			if err != nil {
+				return err
+			}
+
+			loc := r.FindStringSubmatchIndex(str)
+			if loc == nil {
+				return NULL
+			}
+
+			names := r.SubexpNames()
+			named := object.InitHash()
+			for i, name := range names {
+				if name != "" {
+					named.Set(&object.String{Value: name}, groupValue(str, loc, i))
+				}
+			}
+
+			if named.Len() > 0 {
+				return named
+			}
+
+			groups := make([]object.Object, len(names))
+			for i := range names {
+				groups[i] = groupValue(str, loc, i)
+			}
+
+			return &object.Array{Elems: groups}
+		},
+	},
+}
@@ -0,0 +1,483 @@
+package evaluator
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(stringBuiltins)
+}
+
+// stringArg and intArg check one builtin argument, the errors read the
+// same for every builtin using them
+func stringArg(name string, arg object.Object) (string, *object.Error) {
+	str, ok := arg.(*object.String)
+	if !ok {
+		return "", newError("argument to `%s` must be STRING, got %s", name, arg.Type())
+	}
+
+	return str.Value, nil
+}
+
+func intArg(name string, arg object.Object) (int64, *object.Error) {
+	n, ok := arg.(*object.Integer)
+	if !ok {
+		return 0, newError("argument to `%s` must be INTEGER, got %s", name, arg.Type())
+	}
+
+	return n.Value, nil
+}
+
+// stringsArgs checks the number of arguments and that all of them are strings
+func stringsArgs(name string, args []object.Object, want int) ([]string, *object.Error) {
+	if len(args) != want {
+		return nil, newError("wrong number of arguments, got=%d, want=%d", len(args), want)
+	}
+
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, err := stringArg(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = str
+	}
+
+	return strs, nil
+}
+
+// textOf is how a value is written out as text, in a csv cell, an http body
+// or a command argument. like puts but null is empty
+func textOf(obj object.Object) string {
+	if obj == NULL {
+		return ""
+	}
+
+	return obj.Inspect()
+}
+
+func stringsToArray(strs []string) *object.Array {
+	elems := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elems[i] = &object.String{Value: s}
+	}
+
+	return &object.Array{Elems: elems}
+}
+
+// pad repeats with up to width runes, cutting the last repetition short.
+// ok is false when the padding would take more than maxStringLen bytes
+func pad(str string, width int64, with string) (padding string, ok bool) {
+	n := width - int64(utf8.RuneCountInString(str))
+	if n <= 0 || with == "" {
+		return "", true
+	}
+
+	runes := []rune(with)
+	reps := n / int64(len(runes))
+	if reps >= maxStringLen/int64(len(with)) {
+		return "", false
+	}
+
+	return strings.Repeat(with, int(reps)) + string(runes[:n%int64(len(runes))]), true
+}
+
+// format is a printf for script values. a verb is % with the usual flags,
+// width and precision: d, x, o, b for integers, f, e, g for numbers, s and v
+// for any value as puts prints it, q for a quoted string, t for bools and
+// %% for a percent sign.
+func format(spec string, args []object.Object) object.Object {
+	var out strings.Builder
+	next := 0
+
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			out.WriteByte(spec[i])
+			continue
+		}
+
+		j := i + 1
+		for j < len(spec) && strings.IndexByte("+-# 0123456789.", spec[j]) >= 0 {
+			j++
+		}
+
+		if j == len(spec) {
+			return newError("format: missing verb at end of %q", spec)
+		}
+
+		verb := spec[j]
+		directive := spec[i : j+1]
+		i = j
+
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if next >= len(args) {
+			return newError("format: missing argument for %s", directive)
+		}
+		arg := args[next]
+		next++
+
+		var val any
+		switch verb {
+		case 'd', 'x', 'X', 'o', 'b':
+			switch arg := arg.(type) {
+			case *object.Integer:
+				val = arg.Value
+			case *object.BigInt:
+				val = arg.Value
+			default:
+				if verb == 'x' || verb == 'X' {
+					if str, ok := arg.(*object.String); ok {
+						val = str.Value
+						break
+					}
+				}
+				return newError("format: %s needs INTEGER, got %s", directive, arg.Type())
+			}
+		case 'f', 'F', 'e', 'E', 'g', 'G':
+			if !isNumber(arg.Type()) {
+				return newError("format: %s needs a number, got %s", directive, arg.Type())
+			}
+			val = toFloat(arg)
+		case 's', 'v':
+			val = arg.Inspect()
+		case 'q':
+			str, ok := arg.(*object.String)
+			if !ok {
+				return newError("format: %s needs STRING, got %s", directive, arg.Type())
+			}
+			val = str.Value
+		case 't':
+			b, ok := arg.(*object.Bool)
+			if !ok {
+				return newError("format: %s needs BOOL, got %s", directive, arg.Type())
+			}
+			val = b.Value
+		default:
+			return newError("format: unknown verb %s", directive)
+		}
+
+		fmt.Fprintf(&out, directive, val)
+	}
+
+	if next < len(args) {
+		return newError("format: too many arguments, got=%d, used=%d", len(args), next)
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+var stringBuiltins = map[string]*object.Builtin{
+	// split(str, sep?) cuts str around sep, a STRING or REGEX, without sep
+	// around runs of whitespace
+	"split": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("split", args[0])
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				return stringsToArray(strings.Fields(str))
+			}
+
+			if r, ok := args[1].(*object.Regex); ok {
+				return stringsToArray(r.Value.Split(str, -1))
+			}
+
+			sep, err := stringArg("split", args[1])
+			if err != nil {
+				return err
+			}
+
+			return stringsToArray(strings.Split(str, sep))
+		},
+	},
+
+	// join(array, sep?) glues the values as puts prints them
+	"join": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			elems, errObj := elemsOf(ctx, "join", args[0])
+			if errObj != nil {
+				return errObj
+			}
+
+			sep := ""
+			if len(args) == 2 {
+				var err *object.Error
+				if sep, err = stringArg("join", args[1]); err != nil {
+					return err
+				}
+			}
+
+			strs := make([]string, len(elems))
+			for i, elem := range elems {
+				strs[i] = elem.Inspect()
+			}
+
+			return &object.String{Value: strings.Join(strs, sep)}
+		},
+	},
+
+	// trim(str, cutset?) strips whitespace, or the runes in cutset, from both ends
+	"trim": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			strs, err := stringsArgs("trim", args, len(args))
+			if err != nil {
+				return err
+			}
+
+			if len(strs) == 1 {
+				return &object.String{Value: strings.TrimSpace(strs[0])}
+			}
+
+			return &object.String{Value: strings.Trim(strs[0], strs[1])}
+		},
+	},
+
+	"upper": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("upper", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: strings.ToUpper(strs[0])}
+		},
+	},
+
+	"lower": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("lower", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: strings.ToLower(strs[0])}
+		},
+	},
+
+	// replace(str, old, new, n?) replaces the first n matches, all by default.
+	// with a REGEX as old every match is replaced, see regexReplace
+	"replace": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 3 && len(args) != 4 {
+				return newError("wrong number of arguments, got=%d, want=3 or 4", len(args))
+			}
+
+			if r, ok := args[1].(*object.Regex); ok && len(args) == 3 {
+				str, err := stringArg("replace", args[0])
+				if err != nil {
+					return err
+				}
+
+				return regexReplace(ctx, r.Value, str, args[2])
+			}
+
+			strs, err := stringsArgs("replace", args[:3], 3)
+			if err != nil {
+				return err
+			}
+
+			n := int64(-1)
+			if len(args) == 4 {
+				if n, err = intArg("replace", args[3]); err != nil {
+					return err
+				}
+			}
+
+			return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], int(n))}
+		},
+	},
+
+	"contains": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("contains", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(strings.Contains(strs[0], strs[1]))
+		},
+	},
+
+	"starts_with": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("starts_with", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(strings.HasPrefix(strs[0], strs[1]))
+		},
+	},
+
+	"ends_with": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("ends_with", args, 2)
+			if err != nil {
+				return err
+			}
+
+			return nativeBoolToBoolObj(strings.HasSuffix(strs[0], strs[1]))
+		},
+	},
+
+	// rune index of the first match, -1 when there is none
+	"index_of": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("index_of", args, 2)
+			if err != nil {
+				return err
+			}
+
+			i := strings.Index(strs[0], strs[1])
+			if i < 0 {
+				return &object.Integer{Value: -1}
+			}
+
+			return &object.Integer{Value: int64(utf8.RuneCountInString(strs[0][:i]))}
+		},
+	},
+
+	// pad_left(str, width, pad?) pads str to width runes with pad, default a space
+	"pad_left": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return padBuiltin("pad_left", args, func(str, padding string) string { return padding + str })
+		},
+	},
+
+	// pad_right(str, width, pad?) pads str to width runes with pad, default a space
+	"pad_right": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			return padBuiltin("pad_right", args, func(str, padding string) string { return str + padding })
+		},
+	},
+
+	// ARRAY of the one rune strings in str
+	"chars": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("chars", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return stringsToArray(strings.Split(strs[0], ""))
+		},
+	},
+
+	// format(spec, values...) printf style, see format
+	"format": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments, got=%d, want at least 1", len(args))
+			}
+
+			spec, err := stringArg("format", args[0])
+			if err != nil {
+				return err
+			}
+
+			return format(spec, args[1:])
+		},
+	},
+
+	// parse_int(str, base?) parses an integer in base 2 to 36, default 10,
+	// a 0x, 0o or 0b prefix picks the base when it is given as 0
+	"parse_int": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("parse_int", args[0])
+			if err != nil {
+				return err
+			}
+
+			base := int64(10)
+			if len(args) == 2 {
+				if base, err = intArg("parse_int", args[1]); err != nil {
+					return err
+				}
+				if base != 0 && (base < 2 || base > 36) {
+					return newError("invalid base for `parse_int`: %d", base)
+				}
+			}
+
+			i, ok := new(big.Int).SetString(str, int(base))
+			if !ok {
+				return newError("could not parse %q as integer", str)
+			}
+
+			return object.IntegerFromBig(i)
+		},
+	},
+
+	"parse_float": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			strs, err := stringsArgs("parse_float", args, 1)
+			if err != nil {
+				return err
+			}
+
+			f, parseErr := strconv.ParseFloat(strs[0], 64)
+			if parseErr != nil {
+				return newError("could not parse %q as float", strs[0])
+			}
+
+			return &object.Float{Value: f}
+		},
+	},
+}
+
+func padBuiltin(name string, args []object.Object, join func(str, padding string) string) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments, got=%d, want=2 or 3", len(args))
+	}
+
+	str, err := stringArg(name, args[0])
+	if err != nil {
+		return err
+	}
+
+	width, err := intArg(name, args[1])
+	if err != nil {
+		return err
+	}
+
+	with := " "
+	if len(args) == 3 {
+		if with, err = stringArg(name, args[2]); err != nil {
+			return err
+		}
+	}
+
+	padding, ok := pad(str, width, with)
+	if !ok {
+		return newError("width for `%s` too large: %d", name, width)
+	}
+
+	return &object.String{Value: join(str, padding)}
+}
@@ -0,0 +1,209 @@
+package evaluator
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/Aergiaaa/simplescript/ast"
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(taskBuiltins)
+}
+
+// evalSpawnExpr evaluates the callee and arguments right away, only the
+// call itself runs on the new task
+func evalSpawnExpr(node *ast.SpawnExpression, env *object.Environment) object.Object {
+	f := Eval(node.Call.Func, env)
+	if isError(f) {
+		return f
+	}
+
+	args := evalExprs(node.Call.Args, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	ctx := env.Context().Fork()
+	return object.InitTask(func() object.Object {
+		return applyFunc(ctx, f, args)
+	})
+}
+
+var taskBuiltins = map[string]*object.Builtin{
+	// wait for a task and return its result, errors of the task propagate
+	"await": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			task, ok := args[0].(*object.Task)
+			if !ok {
+				return newError("argument to `await` must be TASK, got %s", args[0].Type())
+			}
+
+			res, err := task.Await(ctx)
+			if err != nil {
+				return newError("execution cancelled: %s", err)
+			}
+
+			return res
+		},
+	},
+
+	// new channel, optionally buffered
+	"chan": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got=%d, want 0 or 1", len(args))
+			}
+
+			size := int64(0)
+			if len(args) == 1 {
+				n, ok := args[0].(*object.Integer)
+				if !ok || n.Value < 0 {
+					return newError("argument to `chan` must be a non-negative INTEGER, got %s", args[0].Inspect())
+				}
+				if n.Value > object.MaxChannelSize {
+					return newError("buffer of `chan` too large: %d, the maximum is %d", n.Value, object.MaxChannelSize)
+				}
+				size = n.Value
+			}
+
+			return object.InitChannel(int(size))
+		},
+	},
+
+	// send a value, blocks until a receiver or buffer space is there
+	"send": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=2", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return newError("argument to `send` must be CHANNEL, got %s", args[0].Type())
+			}
+
+			if err := ch.Send(ctx, args[1]); err != nil {
+				return newError("%s", err)
+			}
+
+			return NULL
+		},
+	},
+
+	// receive a value, null once the channel is closed and drained
+	"recv": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return newError("argument to `recv` must be CHANNEL, got %s", args[0].Type())
+			}
+
+			val, ok, err := ch.Recv(ctx)
+			if err != nil {
+				return newError("execution cancelled: %s", err)
+			}
+
+			if !ok {
+				return NULL
+			}
+
+			return val
+		},
+	},
+
+	// close a channel, later sends are errors
+	"close": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return newError("argument to `close` must be CHANNEL, got %s", args[0].Type())
+			}
+
+			ch.Close()
+			return NULL
+		},
+	},
+
+	// select(channels, timeout?) receives from whichever channel is ready
+	// first and returns [index, value], value is null for a closed channel.
+	// with a timeout, in ms or a duration, it returns null when nothing was
+	// ready in time.
+	"select": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok || len(arr.Elems) == 0 {
+				return newError("argument to `select` must be a non-empty ARRAY of CHANNEL, got %s", args[0].Inspect())
+			}
+
+			chans := make([]*object.Channel, len(arr.Elems))
+			cases := make([]reflect.SelectCase, 0, 2*len(arr.Elems)+2)
+			for i, elem := range arr.Elems {
+				ch, ok := elem.(*object.Channel)
+				if !ok {
+					return newError("argument to `select` must be a non-empty ARRAY of CHANNEL, got %s", elem.Type())
+				}
+				chans[i] = ch
+
+				vals, closed := ch.Chans()
+				cases = append(cases,
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(vals)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(closed)},
+				)
+			}
+
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+
+			if len(args) == 2 {
+				timeout, err := durationArg("select", args[1])
+				if err != nil {
+					return err
+				}
+
+				timer := time.NewTimer(timeout)
+				defer timer.Stop()
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)})
+			}
+
+			chosen, recv, _ := reflect.Select(cases)
+			switch {
+			case chosen == 2*len(chans):
+				return newError("execution cancelled: %s", ctx.Err())
+			case chosen > 2*len(chans):
+				return NULL
+			}
+
+			i := chosen / 2
+			idx := &object.Integer{Value: int64(i)}
+
+			if chosen%2 == 0 {
+				return &object.Array{Elems: []object.Object{idx, recv.Interface().(object.Object)}}
+			}
+
+			// closed, but it may still hold buffered values
+			val, ok := chans[i].TryRecv()
+			if !ok {
+				val = NULL
+			}
+
+			return &object.Array{Elems: []object.Object{idx, val}}
+		},
+	},
+}
@@ -0,0 +1,298 @@
+package evaluator
+
+import (
+	"cmp"
+	"math"
+	"time"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func init() {
+	register(timeBuiltins)
+}
+
+func isTime(t object.ObjectType) bool {
+	return t == object.TIME_OBJ || t == object.DURATION_OBJ
+}
+
+func compareOp(op string, c int) (object.Object, bool) {
+	switch op {
+	case "<":
+		return nativeBoolToBoolObj(c < 0), true
+	case ">":
+		return nativeBoolToBoolObj(c > 0), true
+	case "<=":
+		return nativeBoolToBoolObj(c <= 0), true
+	case ">=":
+		return nativeBoolToBoolObj(c >= 0), true
+	default:
+		return nil, false
+	}
+}
+
+// evalTimeInfixExpr is the arithmetic of times and durations: the difference
+// of two times is a duration, a duration moves a time and durations add up
+// and scale by numbers
+func evalTimeInfixExpr(op string, left, right object.Object) object.Object {
+	switch l := left.(type) {
+	case *object.Time:
+		switch r := right.(type) {
+		case *object.Time:
+			if op == "-" {
+				return &object.Duration{Value: l.Value.Sub(r.Value)}
+			}
+			if res, ok := compareOp(op, l.Value.Compare(r.Value)); ok {
+				return res
+			}
+		case *object.Duration:
+			switch op {
+			case "+":
+				return &object.Time{Value: l.Value.Add(r.Value)}
+			case "-":
+				return &object.Time{Value: l.Value.Add(-r.Value)}
+			}
+		}
+	case *object.Duration:
+		switch r := right.(type) {
+		case *object.Time:
+			if op == "+" {
+				return &object.Time{Value: r.Value.Add(l.Value)}
+			}
+		case *object.Duration:
+			switch op {
+			case "+":
+				sum := l.Value + r.Value
+				if (r.Value > 0 && sum < l.Value) || (r.Value < 0 && sum > l.Value) {
+					return durationOverflow(left, op, right)
+				}
+				return &object.Duration{Value: sum}
+			case "-":
+				diff := l.Value - r.Value
+				if (r.Value > 0 && diff > l.Value) || (r.Value < 0 && diff < l.Value) {
+					return durationOverflow(left, op, right)
+				}
+				return &object.Duration{Value: diff}
+			case "/":
+				if r.Value == 0 {
+					return newError("division by zero")
+				}
+				return &object.Float{Value: float64(l.Value) / float64(r.Value)}
+			}
+			if res, ok := compareOp(op, cmp.Compare(l.Value, r.Value)); ok {
+				return res
+			}
+		default:
+			if !isNumber(right.Type()) {
+				break
+			}
+
+			switch op {
+			case "*":
+				return floatDuration(float64(l.Value)*toFloat(right), left, op, right)
+			case "/":
+				if toFloat(right) == 0 {
+					return newError("division by zero")
+				}
+				return floatDuration(float64(l.Value)/toFloat(right), left, op, right)
+			}
+		}
+	default:
+		if r, ok := right.(*object.Duration); ok && op == "*" && isNumber(left.Type()) {
+			return floatDuration(toFloat(left)*float64(r.Value), left, op, right)
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+}
+
+func durationOverflow(left object.Object, op string, right object.Object) *object.Error {
+	return newError("duration overflow: %s %s %s", left.Inspect(), op, right.Inspect())
+}
+
+// floatDuration is the duration of ns nanoseconds, an error when it falls
+// outside the duration range
+func floatDuration(ns float64, left object.Object, op string, right object.Object) object.Object {
+	if math.IsNaN(ns) || ns >= math.MaxInt64 || ns < math.MinInt64 {
+		return durationOverflow(left, op, right)
+	}
+
+	return &object.Duration{Value: time.Duration(ns)}
+}
+
+// durationArg accepts a DURATION or an INTEGER of milliseconds
+func durationArg(name string, arg object.Object) (time.Duration, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Duration:
+		return arg.Value, nil
+	case *object.Integer:
+		if arg.Value > math.MaxInt64/int64(time.Millisecond) || arg.Value < math.MinInt64/int64(time.Millisecond) {
+			return 0, newError("argument to `%s` out of duration range: %d", name, arg.Value)
+		}
+		return time.Duration(arg.Value) * time.Millisecond, nil
+	default:
+		return 0, newError("argument to `%s` must be DURATION or INTEGER, got %s", name, arg.Type())
+	}
+}
+
+func timeArg(name string, arg object.Object) (time.Time, *object.Error) {
+	t, ok := arg.(*object.Time)
+	if !ok {
+		return time.Time{}, newError("argument to `%s` must be TIME, got %s", name, arg.Type())
+	}
+
+	return t.Value, nil
+}
+
+// layoutArg is the optional Go layout at args[i], RFC 3339 by default
+func layoutArg(name string, args []object.Object, i int) (string, *object.Error) {
+	if len(args) <= i {
+		return time.RFC3339, nil
+	}
+
+	return stringArg(name, args[i])
+}
+
+// times come from Context.Clock, layouts are Go's reference time layouts
+// like "2006-01-02 15:04"
+var timeBuiltins = map[string]*object.Builtin{
+	"now": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments, got=%d, want=0", len(args))
+			}
+
+			return &object.Time{Value: ctx.Clock.Now()}
+		},
+	},
+
+	// unix(t?) are the seconds since 1970 of t, or of now
+	"unix": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got=%d, want 0 or 1", len(args))
+			}
+
+			t := ctx.Clock.Now()
+			if len(args) == 1 {
+				var err *object.Error
+				if t, err = timeArg("unix", args[0]); err != nil {
+					return err
+				}
+			}
+
+			return &object.Integer{Value: t.Unix()}
+		},
+	},
+
+	// sleep(ms) or sleep(duration), cut short when the run is cancelled
+	"sleep": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			d, err := durationArg("sleep", args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := ctx.Clock.Sleep(ctx, d); err != nil {
+				return newError("execution cancelled: %s", err)
+			}
+
+			return NULL
+		},
+	},
+
+	// format_time(t, layout?)
+	"format_time": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			t, err := timeArg("format_time", args[0])
+			if err != nil {
+				return err
+			}
+
+			layout, err := layoutArg("format_time", args, 1)
+			if err != nil {
+				return err
+			}
+
+			return &object.String{Value: t.Format(layout)}
+		},
+	},
+
+	// parse_time(str, layout?), times without a zone are UTC
+	"parse_time": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got=%d, want=1 or 2", len(args))
+			}
+
+			str, err := stringArg("parse_time", args[0])
+			if err != nil {
+				return err
+			}
+
+			layout, err := layoutArg("parse_time", args, 1)
+			if err != nil {
+				return err
+			}
+
+			t, parseErr := time.Parse(layout, str)
+			if parseErr != nil {
+				return newError("could not parse %q as time: %s", str, parseErr)
+			}
+
+			return &object.Time{Value: t}
+		},
+	},
+
+	// duration("1h30m") or duration(ms)
+	"duration": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				d, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return newError("could not parse %q as duration", str.Value)
+				}
+				return &object.Duration{Value: d}
+			}
+
+			d, err := durationArg("duration", args[0])
+			if err != nil {
+				return err
+			}
+
+			return &object.Duration{Value: d}
+		},
+	},
+
+	// whole milliseconds of a duration
+	"millis": {
+		Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got=%d, want=1", len(args))
+			}
+
+			d, ok := args[0].(*object.Duration)
+			if !ok {
+				return newError("argument to `millis` must be DURATION, got %s", args[0].Type())
+			}
+
+			return &object.Integer{Value: d.Value.Milliseconds()}
+		},
+	},
+}
@@ -0,0 +1,209 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Aergiaaa/simplescript/evaluator"
+	"github.com/Aergiaaa/simplescript/lexer"
+	"github.com/Aergiaaa/simplescript/object"
+	"github.com/Aergiaaa/simplescript/parser"
+)
+
+type Options struct {
+	Stdin  io.Reader // default os.Stdin
+	Stdout io.Writer // default os.Stdout
+	Stderr io.Writer // default os.Stderr
+
+	// extra or overriding builtins, only visible to this interpreter
+	Builtins map[string]*object.Builtin
+
+	// values bound in the global env before any script runs
+	Globals map[string]object.Object
+
+	// maximum nested function calls, 0 means unlimited
+	MaxDepth int
+
+	// seed of `random`, nil picks a random one
+	Seed *uint64
+
+	// host access granted to scripts, see object.Capability
+	Caps object.Capability
+
+	// directory the file builtins are confined to, "" is the working directory
+	FileRoot string
+
+	// time source of `now` and `sleep`, default the system clock
+	Clock object.Clock
+
+	// script arguments and environment, "key=value" like os.Environ. the
+	// host environment is not visible unless passed here
+	Args []string
+	Env  []string
+}
+
+// Interpreter is an embeddable script runtime. each one owns its own
+// environment and context so many of them can run side by side, calls on
+// the same interpreter are serialized.
+type Interpreter struct {
+	mu  sync.Mutex
+	ctx *object.Context
+	env *object.Environment
+}
+
+func InitInterpreter(opts Options) *Interpreter {
+	ctx := object.InitContext()
+	ctx.MaxDepth = opts.MaxDepth
+	ctx.Caps = opts.Caps
+	ctx.FileRoot = opts.FileRoot
+	ctx.Args = opts.Args
+	ctx.Env = opts.Env
+
+	if opts.Clock != nil {
+		ctx.Clock = opts.Clock
+	}
+
+	if opts.Seed != nil {
+		ctx.Rand.Seed(*opts.Seed)
+	}
+
+	if opts.Stdin != nil {
+		ctx.Stdin = opts.Stdin
+	}
+	if opts.Stdout != nil {
+		ctx.Stdout = opts.Stdout
+	}
+	if opts.Stderr != nil {
+		ctx.Stderr = opts.Stderr
+	}
+
+	for name, b := range opts.Builtins {
+		ctx.Builtins[name] = b
+	}
+
+	env := object.InitContextEnv(ctx)
+	for name, val := range opts.Globals {
+		env.Set(name, val)
+	}
+
+	return &Interpreter{
+		ctx: ctx,
+		env: env,
+	}
+}
+
+// ParseError holds every error reported by the parser
+type ParseError struct {
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	return "parser errors: " + strings.Join(e.Errors, "; ")
+}
+
+// RuntimeError is an object.Error that escaped to the top level
+type RuntimeError struct {
+	Message string
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+// ExitError is returned when a script calls `exit`
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (in *Interpreter) Run(src string) (object.Object, error) {
+	return in.RunContext(context.Background(), src)
+}
+
+// RunContext is Run with cancellation, the script stops at the next function call once ctx is done
+func (in *Interpreter) RunContext(ctx context.Context, src string) (object.Object, error) {
+	l := lexer.InitLexer(src)
+	p := parser.InitParser(l)
+	program := p.Parse()
+
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.Errors()}
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.ctx.Context = ctx
+	defer func() { in.ctx.Context = context.Background() }()
+
+	return result(evaluator.Eval(program, in.env))
+}
+
+// Call looks up a global function by name and applies it to args
+func (in *Interpreter) Call(name string, args ...object.Object) (object.Object, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	fn, ok := in.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("function not found: %s", name)
+	}
+
+	return result(evaluator.Apply(in.ctx, fn, args))
+}
+
+func (in *Interpreter) Get(name string) (object.Object, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	return in.env.Get(name)
+}
+
+func (in *Interpreter) Set(name string, val object.Object) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.env.Set(name, val)
+}
+
+// Register exposes a Go func to scripts, see object.WrapFunc for the conversion rules
+func (in *Interpreter) Register(name string, fn any) error {
+	b, err := object.WrapFunc(fn)
+	if err != nil {
+		return err
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.ctx.Builtins[name] = b
+	return nil
+}
+
+// SetValue is Set for plain Go values, converted with object.FromGo
+func (in *Interpreter) SetValue(name string, v any) error {
+	obj, err := object.FromGo(v)
+	if err != nil {
+		return err
+	}
+
+	in.Set(name, obj)
+	return nil
+}
+
+func result(obj object.Object) (object.Object, error) {
+	if errObj, ok := obj.(*object.Error); ok {
+		if errObj.Exit {
+			return nil, &ExitError{Code: errObj.Code}
+		}
+		return nil, &RuntimeError{Message: errObj.Message}
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,338 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Aergiaaa/simplescript/object"
+)
+
+func TestRun(t *testing.T) {
+	in := InitInterpreter(Options{})
+
+	res, err := in.Run("let add = ft(x, y) { x + y }; add(2, 3)")
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	testInteger(t, res, 5)
+}
+
+func TestRunErrors(t *testing.T) {
+	in := InitInterpreter(Options{})
+
+	_, err := in.Run("let = 5")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got=%T (%v)", err, err)
+	}
+
+	_, err = in.Run("5 + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError, got=%T (%v)", err, err)
+	}
+
+	if runtimeErr.Message != "type mismatch: INTEGER + BOOL" {
+		t.Errorf("wrong error message, got=%q", runtimeErr.Message)
+	}
+}
+
+func TestCallGetSet(t *testing.T) {
+	in := InitInterpreter(Options{
+		Globals: map[string]object.Object{
+			"base": &object.Integer{Value: 10},
+		},
+	})
+
+	if _, err := in.Run("let addBase = ft(x) { x + base };"); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	res, err := in.Call("addBase", &object.Integer{Value: 5})
+	if err != nil {
+		t.Fatalf("Call returned error: %s", err)
+	}
+	testInteger(t, res, 15)
+
+	in.Set("base", &object.Integer{Value: 100})
+	res, err = in.Call("addBase", &object.Integer{Value: 5})
+	if err != nil {
+		t.Fatalf("Call returned error: %s", err)
+	}
+	testInteger(t, res, 105)
+
+	base, ok := in.Get("base")
+	if !ok {
+		t.Fatalf("global base not found")
+	}
+	testInteger(t, base, 100)
+
+	if _, err := in.Call("missing"); err == nil {
+		t.Errorf("expected error calling missing function")
+	}
+
+	if _, err := in.Call("addBase"); err == nil {
+		t.Errorf("expected error calling with wrong number of arguments")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var out bytes.Buffer
+
+	in := InitInterpreter(Options{
+		Stdout: &out,
+		Builtins: map[string]*object.Builtin{
+			"double": {
+				Fn: func(ctx *object.Context, args ...object.Object) object.Object {
+					return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+				},
+			},
+		},
+	})
+
+	if _, err := in.Run(`puts(double(21)); puts("done")`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	if out.String() != "42\ndone\n" {
+		t.Errorf("wrong output, got=%q", out.String())
+	}
+
+	other := InitInterpreter(Options{})
+	if _, err := other.Run("double(1)"); err == nil {
+		t.Errorf("builtin leaked into another interpreter")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	in := InitInterpreter(Options{MaxDepth: 50})
+
+	_, err := in.Run("let loop = ft(x) { loop(x + 1) }; loop(0)")
+	if err == nil || err.Error() != "maximum call depth exceeded: 50" {
+		t.Errorf("expected depth error, got=%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = in.RunContext(ctx, "let f = ft() { 1 }; f()")
+	if err == nil || err.Error() != "execution cancelled: context canceled" {
+		t.Errorf("expected cancellation error, got=%v", err)
+	}
+
+	res, err := in.Run("let f = ft() { 1 }; f()")
+	if err != nil {
+		t.Fatalf("interpreter unusable after cancellation: %s", err)
+	}
+	testInteger(t, res, 1)
+}
+
+func TestSeed(t *testing.T) {
+	src := "map(range(5), ft(i) { random(1000) })"
+
+	for _, seed := range []uint64{0, 42} {
+		a, err := InitInterpreter(Options{Seed: &seed}).Run(src)
+		if err != nil {
+			t.Fatalf("run failed: %s", err)
+		}
+
+		b, err := InitInterpreter(Options{Seed: &seed}).Run(src)
+		if err != nil {
+			t.Fatalf("run failed: %s", err)
+		}
+
+		if a.Inspect() != b.Inspect() {
+			t.Errorf("seed %d gave different values, %s and %s", seed, a.Inspect(), b.Inspect())
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	var out bytes.Buffer
+	in := InitInterpreter(Options{Stdout: &out, Args: []string{"4"}})
+
+	_, err := in.Run(`puts("before"); exit(int(head(args()))); puts("after")`)
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 4 {
+		t.Fatalf("expected exit status 4, got=%v", err)
+	}
+
+	if out.String() != "before\n" {
+		t.Errorf("script not stopped by exit, output=%q", out.String())
+	}
+
+	_, err = in.Run("1 + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Errorf("expected a runtime error, got=%v", err)
+	}
+}
+
+func TestStdin(t *testing.T) {
+	var out bytes.Buffer
+	in := InitInterpreter(Options{
+		Stdin:  strings.NewReader("b\na\nc\n"),
+		Stdout: &out,
+	})
+
+	_, err := in.Run(`each(sort(collect(lines())), puts)`)
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	if out.String() != "a\nb\nc\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
+func TestSleepCancelled(t *testing.T) {
+	in := InitInterpreter(Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := in.RunContext(ctx, "sleep(60000)")
+	if err == nil || err.Error() != "execution cancelled: context deadline exceeded" {
+		t.Errorf("expected cancellation error, got=%v", err)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("sleep not cut short by cancellation")
+	}
+}
+
+func TestFrozenClock(t *testing.T) {
+	clock := object.InitFrozenClock(time.Unix(1000, 0))
+	in := InitInterpreter(Options{Clock: clock})
+
+	res, err := in.Run("sleep(5000); unix()")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	testInteger(t, res, 1005)
+
+	clock.Advance(time.Minute)
+	res, _ = in.Run("unix()")
+	testInteger(t, res, 1065)
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			in := InitInterpreter(Options{})
+			src := fmt.Sprintf(`
+			let n = %d;
+			let fib = ft(x) { if (x < 2) { x } else { fib(x - 1) + fib(x - 2) } };
+			fib(15) + n`, i)
+
+			res, err := in.Run(src)
+			if err != nil {
+				t.Errorf("Run returned error: %s", err)
+				return
+			}
+			testInteger(t, res, int64(610+i))
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestRegister(t *testing.T) {
+	in := InitInterpreter(Options{})
+
+	err := in.Register("repeat", func(s string, n int) (string, error) {
+		if n < 0 {
+			return "", errors.New("negative count")
+		}
+		return strings.Repeat(s, n), nil
+	})
+	if err != nil {
+		t.Fatalf("Register returned error: %s", err)
+	}
+
+	if err := in.SetValue("config", map[string]int{"times": 3}); err != nil {
+		t.Fatalf("SetValue returned error: %s", err)
+	}
+
+	res, err := in.Run(`repeat("ab", config["times"])`)
+	if err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	var s string
+	if err := object.ToGo(res, &s); err != nil || s != "ababab" {
+		t.Errorf("wrong result, got=%q (%v)", s, err)
+	}
+
+	_, err = in.Run(`repeat("ab", -1)`)
+	if err == nil || err.Error() != "negative count" {
+		t.Errorf("expected Go error to surface, got=%v", err)
+	}
+
+	// the task keeps looking up builtins after Run returns
+	if _, err := in.Run(`let spin = ft(n) { if (n > 0) { spin(abs(n) - 1) } }; spawn spin(20000)`); err != nil {
+		t.Fatalf("Run returned error: %s", err)
+	}
+
+	for i := range 100 {
+		if err := in.Register(fmt.Sprintf("f%d", i), func() int { return i }); err != nil {
+			t.Fatalf("Register returned error: %s", err)
+		}
+	}
+}
+
+func TestFloatValues(t *testing.T) {
+	in := InitInterpreter(Options{})
+	in.SetValue("half", 0.5)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"half * 4 + 1", "3.0"},
+		{"-half", "-0.5"},
+		{"half < 1", "true"},
+		{"half * 2 == 1", "true"},
+	}
+
+	for _, tt := range tests {
+		res, err := in.Run(tt.input)
+		if err != nil {
+			t.Errorf("Run(%q) returned error: %s", tt.input, err)
+			continue
+		}
+
+		if res.Inspect() != tt.expected {
+			t.Errorf("Run(%q) wrong, got=%s, want=%s", tt.input, res.Inspect(), tt.expected)
+		}
+	}
+}
+
+func testInteger(t *testing.T, obj object.Object, expected int64) bool {
+	res, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if res.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", res.Value, expected)
+		return false
+	}
+
+	return true
+}
@@ -1,12 +1,22 @@
 package lexer
 
-import "github.com/Aergiaaa/simplescript/token"
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/Aergiaaa/simplescript/token"
+)
+
+// Lexer decodes its input as UTF-8, position and readPosition are byte
+// offsets of the current and next rune
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
-	char         byte
+	char         rune
+
+	errors []string
 }
 
 func InitLexer(s string) *Lexer {
@@ -15,15 +25,24 @@ func InitLexer(s string) *Lexer {
 	return l
 }
 
+// Errors returns the invalid UTF-8 sequences met so far
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
 func (l *Lexer) readChar() {
+	size := 0
 	if l.readPosition >= len(l.input) {
 		l.char = 0
 	} else {
-		l.char = l.input[l.readPosition]
+		l.char, size = utf8.DecodeRuneInString(l.input[l.readPosition:])
+		if l.char == utf8.RuneError && size == 1 {
+			l.errors = append(l.errors, fmt.Sprintf("invalid UTF-8 encoding at offset %d", l.readPosition))
+		}
 	}
 
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += max(size, 1)
 }
 
 func (l *Lexer) NextToken() token.Token {
@@ -149,21 +168,28 @@ func (l *Lexer) readNum() string {
 	return l.input[pos:l.position]
 }
 
+// readIdentifier reads a name, letters followed by letters and digits
 func (l *Lexer) readIdentifier() string {
 	pos := l.position
-	for isLetter(l.char) {
+	for isLetter(l.char) || isDigit(l.char) {
+		l.readChar()
+	}
+
+	// a trailing ! marks a builtin that mutates in place, `x!=y` stays a comparison
+	if l.char == '!' && l.peekChar() != '=' {
 		l.readChar()
 	}
 
 	return l.input[pos:l.position]
 }
 
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
 
-	return l.input[l.readPosition]
+	char, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return char
 }
 
 func (l *Lexer) skipWhiteSpace() {
@@ -172,10 +198,10 @@ func (l *Lexer) skipWhiteSpace() {
 	}
 }
 
-func isDigit(char byte) bool {
+func isDigit(char rune) bool {
 	return '0' <= char && '9' >= char
 }
 
-func isLetter(char byte) bool {
-	return 'a' <= char && 'z' >= char || 'A' <= char && 'Z' >= char || char == '_'
+func isLetter(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
 }
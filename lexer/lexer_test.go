@@ -69,6 +69,9 @@ func TestNextTokenIdent(t *testing.T) {
 	[1,2];
 
 	{"foo": "bar"}
+
+	"a" in b
+	log10 x2y 2x
 	 `
 
 	tests := []struct {
@@ -141,6 +144,13 @@ func TestNextTokenIdent(t *testing.T) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.STRING, "a"},
+		{token.IN, "in"},
+		{token.IDENT, "b"},
+		{token.IDENT, "log10"},
+		{token.IDENT, "x2y"},
+		{token.INT, "2"},
+		{token.IDENT, "x"},
 		{token.EOF, ""},
 	}
 
@@ -159,3 +169,72 @@ func TestNextTokenIdent(t *testing.T) {
 		}
 	}
 }
+
+func TestNextTokenUnicode(t *testing.T) {
+	inp := `let café = "héllo 世界"; π_r + ñ; append!(a); a!=b`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "café"},
+		{token.ASSIGN, "="},
+		{token.STRING, "héllo 世界"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "π_r"},
+		{token.PLUS, "+"},
+		{token.IDENT, "ñ"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "append!"},
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.NEQ, "!="},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	}
+
+	l := InitLexer(inp)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - TokenType wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - Literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	l := InitLexer("let a = \"ok\xff\"; \xfe")
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := []string{
+		"invalid UTF-8 encoding at offset 11",
+		"invalid UTF-8 encoding at offset 15",
+	}
+
+	if len(l.Errors()) != len(expected) {
+		t.Fatalf("wrong number of errors. got=%v", l.Errors())
+	}
+
+	for i, msg := range expected {
+		if l.Errors()[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, l.Errors()[i])
+		}
+	}
+}
@@ -1,52 +1,91 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 
-	"github.com/Aergiaaa/simplescript/evaluator"
-	"github.com/Aergiaaa/simplescript/lexer"
+	"github.com/Aergiaaa/simplescript/interpreter"
 	"github.com/Aergiaaa/simplescript/object"
-	"github.com/Aergiaaa/simplescript/parser"
 	"github.com/Aergiaaa/simplescript/repl"
 )
 
+// exit codes of `idiot run`: the code given to `exit`, 0 when the script
+// ends normally, 1 for an uncaught error and 2 for a bad invocation
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
 func main() {
-	if len(os.Args) >= 3 && os.Args[1] == "run" {
-		filename := os.Args[2]
+	if len(os.Args) >= 2 && os.Args[1] == "run" {
+		flags := flag.NewFlagSet("run", flag.ExitOnError)
+		flags.Usage = func() {
+			fmt.Fprintf(os.Stderr, "usage: idiot run [flags] file.simp [args...]\n")
+			flags.PrintDefaults()
+		}
+		files := flags.String("allow-files", "", "let the script use files under `dir`")
+		network := flags.Bool("allow-net", false, "let the script make and serve http requests")
+		commands := flags.Bool("allow-exec", false, "let the script run commands")
+		flags.Parse(os.Args[2:])
+
+		if flags.NArg() < 1 {
+			flags.Usage()
+			os.Exit(exitUsage)
+		}
+		filename := flags.Arg(0)
 
 		// check the extension
 		if filepath.Ext(filename) != ".simp" {
 			fmt.Fprintf(os.Stderr, "Error file must have .simp extension\n")
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 
 		// exec file mode
 		content, err := os.ReadFile(filename)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 		input := string(content)
 
-		env := object.InitEnv()
-		l := lexer.InitLexer(input)
-		p := parser.InitParser(l)
-		program := p.Parse()
+		opts := interpreter.Options{
+			Args: flags.Args()[1:],
+			Env:  os.Environ(),
+		}
+		if *files != "" {
+			opts.Caps |= object.CapFiles
+			opts.FileRoot = *files
+		}
+		if *network {
+			opts.Caps |= object.CapNet
+		}
+		if *commands {
+			opts.Caps |= object.CapExec
+		}
+
+		in := interpreter.InitInterpreter(opts)
+		_, err = in.Run(input)
 
-		if len(p.Errors()) != 0 {
-			for _, err := range p.Errors() {
-				fmt.Fprintf(os.Stderr, "Parser Error: %s\n", err)
+		var parseErr *interpreter.ParseError
+		if errors.As(err, &parseErr) {
+			for _, msg := range parseErr.Errors {
+				fmt.Fprintf(os.Stderr, "Parser Error: %s\n", msg)
 			}
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 
-		result := evaluator.Eval(program, env)
-		if result != nil && result.Type() == object.ERR_OBJ {
-			fmt.Fprintf(os.Stderr, "%s\n", result.Inspect())
-			os.Exit(1)
+		var exitErr *interpreter.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+			os.Exit(exitError)
 		}
 		return
 	}
@@ -0,0 +1,227 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Context is the per-interpreter execution state. every environment created
+// from the same root shares it, so two interpreters never see each other's
+// builtins, streams or limits. builtins receive it on every call.
+type Context struct {
+	context.Context // cancellation for the whole run
+
+	// Stdin is buffered on the first read, use SetStdin to replace it
+	// after that
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// looked up before the package builtins, so a host can add or override
+	Builtins map[string]*Builtin
+
+	// maximum nested function calls, 0 means unlimited
+	MaxDepth int
+
+	// source of `random`, reseed it for a deterministic sequence
+	Rand *Rand
+
+	// what scripts may touch outside the interpreter, nothing by default
+	Caps Capability
+
+	// directory the file builtins are confined to, "" is the working directory
+	FileRoot string
+
+	// time source of `now` and `sleep`
+	Clock Clock
+
+	// what `args` and `env` return, env entries are "key=value"
+	Args []string
+	Env  []string
+
+	// atomic because generators and iterators may run on another task's goroutine
+	depth atomic.Int64
+
+	// buffered Stdin, shared with forks so no task loses another's input
+	in *input
+}
+
+func InitContext() *Context {
+	return &Context{
+		Context:  context.Background(),
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Builtins: make(map[string]*Builtin),
+		Rand:     InitRand(rand.Uint64()),
+		Clock:    SystemClock,
+		in:       &input{},
+	}
+}
+
+// Fork returns the context of a new task, it shares everything but the call
+// depth and Builtins, a copy so the host can register more while the task runs
+func (c *Context) Fork() *Context {
+	return &Context{
+		Context:  c.Context,
+		Stdin:    c.Stdin,
+		Stdout:   c.Stdout,
+		Stderr:   c.Stderr,
+		Builtins: maps.Clone(c.Builtins),
+		MaxDepth: c.MaxDepth,
+		Rand:     c.Rand,
+		Caps:     c.Caps,
+		FileRoot: c.FileRoot,
+		Clock:    c.Clock,
+		Args:     c.Args,
+		Env:      c.Env,
+		in:       c.in,
+	}
+}
+
+func (c *Context) Allowed(cap Capability) bool {
+	return c.Caps&cap == cap
+}
+
+// Enter records a function call, it report false when MaxDepth is reached
+func (c *Context) Enter() bool {
+	if c.depth.Add(1) > int64(c.MaxDepth) && c.MaxDepth > 0 {
+		c.depth.Add(-1)
+		return false
+	}
+
+	return true
+}
+
+func (c *Context) Leave() {
+	c.depth.Add(-1)
+}
+
+type input struct {
+	mu  sync.Mutex
+	src io.Reader // set by SetStdin, which forks see too
+	buf *bufio.Reader
+}
+
+// SetStdin replaces Stdin and drops what was buffered from the old one, for
+// this context and every fork sharing its input
+func (c *Context) SetStdin(r io.Reader) {
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+
+	c.Stdin = r
+	c.in.src = r
+	c.in.buf = nil
+}
+
+// reader returns the buffer over Stdin, created on the first read. a Stdin
+// that already is a *bufio.Reader is used as is, so a host reading through
+// the same buffer keeps its place.
+func (c *Context) reader() *bufio.Reader {
+	if c.in.buf == nil {
+		src := c.in.src
+		if src == nil {
+			src = c.Stdin
+		}
+
+		if buf, ok := src.(*bufio.Reader); ok {
+			c.in.buf = buf
+		} else {
+			c.in.buf = bufio.NewReader(src)
+		}
+	}
+
+	return c.in.buf
+}
+
+// ReadLine reads the next line of Stdin without its line ending, ok is
+// false at the end of the input
+func (c *Context) ReadLine() (line string, ok bool, err error) {
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+
+	line, err = c.reader().ReadString('\n')
+	if err == io.EOF {
+		return line, line != "", nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	line = strings.TrimSuffix(line[:len(line)-1], "\r")
+	return line, true, nil
+}
+
+// ReadAll reads the rest of Stdin
+func (c *Context) ReadAll() (string, error) {
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+
+	b, err := io.ReadAll(c.reader())
+	return string(b), err
+}
+
+// Capability grants scripts access to part of the host
+type Capability uint
+
+const (
+	CapFiles Capability = 1 << iota // read_file, write_file, ...
+	CapNet                          // http_get, http_request, http_serve
+	CapExec                         // exec
+)
+
+// Rand is a random source safe to share between tasks
+type Rand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func InitRand(seed uint64) *Rand {
+	return &Rand{rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (r *Rand) Seed(seed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rnd = rand.New(rand.NewPCG(seed, seed))
+}
+
+// Float64 is in [0, 1)
+func (r *Rand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rnd.Float64()
+}
+
+// Int64N is in [0, n), n must be positive
+func (r *Rand) Int64N(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rnd.Int64N(n)
+}
+
+func (r *Rand) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rnd.Uint64()
+}
+
+// Uint64N is in [0, n), n must be positive
+func (r *Rand) Uint64N(n uint64) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rnd.Uint64N(n)
+}
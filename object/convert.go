@@ -0,0 +1,501 @@
+package object
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+)
+
+var (
+	objectType  = reflect.TypeFor[Object]()
+	errorType   = reflect.TypeFor[error]()
+	contextType = reflect.TypeFor[context.Context]()
+	bigIntType  = reflect.TypeFor[*big.Int]()
+)
+
+// FromGo converts a Go value into its script counterpart. ints and uints
+// become INTEGER, *big.Int INTEGER or BIGINT, floats FLOAT, slices and
+// arrays ARRAY, maps (in sorted key order) and structs (in field order)
+// HASH and funcs BUILTIN. struct fields are keyed by name unless tagged
+// with `simp:"name"`, `simp:"-"` skips the field. cyclic values are an
+// error.
+func FromGo(v any) (Object, error) {
+	if v == nil {
+		return NULL, nil
+	}
+
+	if obj, ok := v.(Object); ok {
+		return obj, nil
+	}
+
+	return fromValue(reflect.ValueOf(v), map[visit]bool{})
+}
+
+// visit identifies a pointer, map or slice being converted, reaching one
+// again while it is still being converted means the value is cyclic
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func fromValue(v reflect.Value, seen map[visit]bool) (Object, error) {
+	if v.IsValid() && v.Type().Implements(objectType) {
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return v.Interface().(Object), nil
+	}
+
+	if v.IsValid() && v.Type() == bigIntType && !v.IsNil() {
+		return IntegerFromBig(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !v.IsNil() && (v.Kind() != reflect.Slice || v.Len() > 0) {
+			key := visit{ptr: v.Pointer(), typ: v.Type()}
+			if seen[key] {
+				return nil, fmt.Errorf("cannot convert cyclic %s", v.Type())
+			}
+			seen[key] = true
+			defer delete(seen, key)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return NULL, nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return fromValue(v.Elem(), seen)
+	case reflect.Bool:
+		if v.Bool() {
+			return TRUE, nil
+		}
+		return FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > 1<<63-1 {
+			return nil, fmt.Errorf("cannot convert %d to INTEGER: overflow", u)
+		}
+		return &Integer{Value: int64(u)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return NULL, nil
+		}
+
+		elems := make([]Object, v.Len())
+		for i := range elems {
+			elem, err := fromValue(v.Index(i), seen)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return &Array{Elems: elems}, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return NULL, nil
+		}
+
+		hash := InitHash()
+		for _, mapKey := range sortedKeys(v) {
+			key, err := fromValue(mapKey, seen)
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := fromValue(v.MapIndex(mapKey), seen)
+			if err != nil {
+				return nil, err
+			}
+
+			if !hash.Set(key, val) {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+		}
+		return hash, nil
+	case reflect.Struct:
+		hash := InitHash()
+		for i := 0; i < v.NumField(); i++ {
+			name, ok := fieldName(v.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			val, err := fromValue(v.Field(i), seen)
+			if err != nil {
+				return nil, err
+			}
+
+			hash.Set(&String{Value: name}, val)
+		}
+		return hash, nil
+	case reflect.Func:
+		if v.IsNil() {
+			return NULL, nil
+		}
+		return wrapValue(v)
+	default:
+		return nil, fmt.Errorf("cannot convert %s to object", v.Type())
+	}
+}
+
+// sortedKeys orders the keys of a Go map so the resulting hash is
+// deterministic, keys of other kinds than string and numbers keep map order
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		switch a.Kind() {
+		case reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(a.Int(), b.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(a.Uint(), b.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(a.Float(), b.Float())
+		default:
+			return 0
+		}
+	})
+
+	return keys
+}
+
+// ToGo stores obj into the value pointed to by ptr, converting as needed.
+// into an `any` target INTEGER becomes int64, FLOAT float64, ARRAY []any
+// and HASH map[string]any (keys through Inspect).
+func ToGo(obj Object, ptr any) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("ToGo needs a non-nil pointer, got %T", ptr)
+	}
+
+	val, err := toValue(obj, v.Type().Elem(), map[Object]bool{})
+	if err != nil {
+		return err
+	}
+
+	v.Elem().Set(val)
+	return nil
+}
+
+func toValue(obj Object, t reflect.Type, seen map[Object]bool) (reflect.Value, error) {
+	if t.Implements(objectType) && reflect.TypeOf(obj).AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+
+	if obj == NULL {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+	}
+
+	if t == bigIntType {
+		switch num := obj.(type) {
+		case *Integer:
+			return reflect.ValueOf(big.NewInt(num.Value)), nil
+		case *BigInt:
+			return reflect.ValueOf(new(big.Int).Set(num.Value)), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			break
+		}
+
+		val, err := toValue(obj, naturalType(obj), seen)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return val.Convert(t), nil
+	case reflect.Pointer:
+		val, err := toValue(obj, t.Elem(), seen)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(val)
+		return ptr, nil
+	case reflect.Bool:
+		if b, ok := obj.(*Bool); ok {
+			return reflect.ValueOf(b.Value).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, ok := obj.(*BigInt); ok {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s: overflow", obj.Inspect(), t)
+		}
+		if i, ok := obj.(*Integer); ok {
+			val := reflect.New(t).Elem()
+			if val.OverflowInt(i.Value) {
+				return reflect.Value{}, fmt.Errorf("cannot convert %d to %s: overflow", i.Value, t)
+			}
+			val.SetInt(i.Value)
+			return val, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*Integer); ok {
+			val := reflect.New(t).Elem()
+			if i.Value < 0 || val.OverflowUint(uint64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("cannot convert %d to %s: overflow", i.Value, t)
+			}
+			val.SetUint(uint64(i.Value))
+			return val, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch num := obj.(type) {
+		case *Float:
+			return reflect.ValueOf(num.Value).Convert(t), nil
+		case *Integer:
+			return reflect.ValueOf(float64(num.Value)).Convert(t), nil
+		case *BigInt:
+			f, _ := new(big.Float).SetInt(num.Value).Float64()
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*String); ok {
+			return reflect.ValueOf(s.Value).Convert(t), nil
+		}
+	case reflect.Slice:
+		if arr, ok := obj.(*Array); ok {
+			if seen[arr] {
+				return reflect.Value{}, fmt.Errorf("cannot convert cyclic ARRAY to %s", t)
+			}
+			seen[arr] = true
+			defer delete(seen, arr)
+
+			slice := reflect.MakeSlice(t, len(arr.Elems), len(arr.Elems))
+			for i, elem := range arr.Elems {
+				val, err := toValue(elem, t.Elem(), seen)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				slice.Index(i).Set(val)
+			}
+			return slice, nil
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*Hash); ok {
+			if seen[hash] {
+				return reflect.Value{}, fmt.Errorf("cannot convert cyclic HASH to %s", t)
+			}
+			seen[hash] = true
+			defer delete(seen, hash)
+
+			m := reflect.MakeMapWithSize(t, hash.Len())
+			for _, pair := range hash.Items() {
+				key, err := toMapKey(pair.Key, t.Key(), seen)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+
+				val, err := toValue(pair.Val, t.Elem(), seen)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				m.SetMapIndex(key, val)
+			}
+			return m, nil
+		}
+	case reflect.Struct:
+		if hash, ok := obj.(*Hash); ok {
+			if seen[hash] {
+				return reflect.Value{}, fmt.Errorf("cannot convert cyclic HASH to %s", t)
+			}
+			seen[hash] = true
+			defer delete(seen, hash)
+
+			st := reflect.New(t).Elem()
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				name, ok := fieldName(field)
+				if !ok {
+					continue
+				}
+
+				pair, ok := hash.Get(&String{Value: name})
+				if !ok {
+					continue
+				}
+
+				val, err := toValue(pair.Val, field.Type, seen)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("field %s: %w", name, err)
+				}
+				st.Field(i).Set(val)
+			}
+			return st, nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", obj.Type(), t)
+}
+
+// naturalType is the Go type an object becomes when the target is `any`
+func naturalType(obj Object) reflect.Type {
+	switch obj.(type) {
+	case *Integer:
+		return reflect.TypeFor[int64]()
+	case *BigInt:
+		return bigIntType
+	case *Float:
+		return reflect.TypeFor[float64]()
+	case *String:
+		return reflect.TypeFor[string]()
+	case *Bool:
+		return reflect.TypeFor[bool]()
+	case *Array:
+		return reflect.TypeFor[[]any]()
+	case *Hash:
+		return reflect.TypeFor[map[string]any]()
+	default:
+		return reflect.TypeOf(obj)
+	}
+}
+
+func toMapKey(key Object, t reflect.Type, seen map[Object]bool) (reflect.Value, error) {
+	if t.Kind() == reflect.String {
+		if _, ok := key.(*String); !ok {
+			return reflect.ValueOf(key.Inspect()).Convert(t), nil
+		}
+	}
+
+	return toValue(key, t, seen)
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("simp")
+	switch tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// WrapFunc turns any Go func into a builtin. arguments are converted with
+// ToGo rules and results with FromGo, a trailing non-nil error result
+// becomes an ERROR, several results come back as an ARRAY. a leading
+// context.Context parameter receives the calling Context. a panic in fn
+// is recovered and returned as an ERROR.
+func WrapFunc(fn any) (*Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("WrapFunc needs a func, got %T", fn)
+	}
+
+	return wrapValue(v)
+}
+
+func wrapValue(fn reflect.Value) (*Builtin, error) {
+	t := fn.Type()
+
+	withCtx := t.NumIn() > 0 && t.In(0) == contextType
+
+	return &Builtin{
+		Fn: func(ctx *Context, args ...Object) (result Object) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = &Error{Message: fmt.Sprintf("panic in wrapped func: %v", r)}
+				}
+			}()
+
+			skip := 0
+			if withCtx {
+				skip = 1
+			}
+
+			in, errObj := funcArgs(t, skip, args)
+			if errObj != nil {
+				return errObj
+			}
+
+			if withCtx {
+				in = append([]reflect.Value{reflect.ValueOf(ctx)}, in...)
+			}
+
+			return funcResult(t, fn.Call(in))
+		},
+	}, nil
+}
+
+// funcArgs converts args for the parameters of t after the first skip ones
+func funcArgs(t reflect.Type, skip int, args []Object) ([]reflect.Value, *Error) {
+	want := t.NumIn() - skip
+	if t.IsVariadic() {
+		if len(args) < want-1 {
+			return nil, &Error{Message: fmt.Sprintf("wrong number of arguments, got=%d, want at least %d", len(args), want-1)}
+		}
+	} else if len(args) != want {
+		return nil, &Error{Message: fmt.Sprintf("wrong number of arguments, got=%d, want=%d", len(args), want)}
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		argType := t.In(skip + min(i, want-1))
+		if t.IsVariadic() && i >= want-1 {
+			argType = argType.Elem()
+		}
+
+		val, err := toValue(arg, argType, map[Object]bool{})
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("argument %d: %s", i+1, err)}
+		}
+		in[i] = val
+	}
+
+	return in, nil
+}
+
+func funcResult(t reflect.Type, out []reflect.Value) Object {
+	if n := t.NumOut(); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return &Error{Message: err.Error()}
+		}
+		out = out[:n-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return NULL
+	case 1:
+		obj, err := fromValue(out[0], map[visit]bool{})
+		if err != nil {
+			return &Error{Message: err.Error()}
+		}
+		return obj
+	default:
+		elems := make([]Object, len(out))
+		for i, val := range out {
+			obj, err := fromValue(val, map[visit]bool{})
+			if err != nil {
+				return &Error{Message: err.Error()}
+			}
+			elems[i] = obj
+		}
+		return &Array{Elems: elems}
+	}
+}
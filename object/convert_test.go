@@ -0,0 +1,198 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X      int
+	Y      int
+	Label  string `simp:"label"`
+	hidden bool
+	Skip   string `simp:"-"`
+}
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{42, "42"},
+		{uint8(7), "7"},
+		{1.5, "1.5"},
+		{2.0, "2.0"},
+		{"hi", "hi"},
+		{true, "true"},
+		{[]int{1, 2, 3}, "[1, 2, 3]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "{a: 1, b: 2, c: 3}"},
+		{&point{X: 1}, "{X: 1, Y: 0, label: }"},
+		{[]any{1, "a", nil}, "[1, a, null]"},
+		{big.NewInt(5), "5"},
+	}
+
+	for _, tt := range tests {
+		obj, err := FromGo(tt.input)
+		if err != nil {
+			t.Errorf("FromGo(%#v) returned error: %s", tt.input, err)
+			continue
+		}
+
+		if obj.Inspect() != tt.expected {
+			t.Errorf("FromGo(%#v) wrong, got=%q, want=%q", tt.input, obj.Inspect(), tt.expected)
+		}
+	}
+
+	if _, err := FromGo(uint64(1 << 63)); err == nil {
+		t.Errorf("expected overflow error")
+	}
+
+	if _, err := FromGo(make(chan int)); err == nil {
+		t.Errorf("expected error for channel")
+	}
+
+	type node struct{ Next *node }
+	loop := &node{}
+	loop.Next = loop
+	if _, err := FromGo(loop); err == nil {
+		t.Errorf("expected error for pointer cycle")
+	}
+
+	self := map[string]any{}
+	self["self"] = self
+	if _, err := FromGo(self); err == nil {
+		t.Errorf("expected error for map cycle")
+	}
+
+	shared := []int{1}
+	if obj, err := FromGo([][]int{shared, shared}); err != nil || obj.Inspect() != "[[1], [1]]" {
+		t.Errorf("shared slice wrong, got=%v (%v)", obj, err)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	arr := &Array{Elems: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}
+
+	var ints []int
+	if err := ToGo(arr, &ints); err != nil || !reflect.DeepEqual(ints, []int{1, 2}) {
+		t.Errorf("ToGo []int wrong, got=%v (%v)", ints, err)
+	}
+
+	var anything any
+	if err := ToGo(arr, &anything); err != nil || !reflect.DeepEqual(anything, []any{int64(1), int64(2)}) {
+		t.Errorf("ToGo any wrong, got=%#v (%v)", anything, err)
+	}
+
+	var f float64
+	if err := ToGo(&Integer{Value: 3}, &f); err != nil || f != 3 {
+		t.Errorf("ToGo float64 wrong, got=%v (%v)", f, err)
+	}
+
+	hash, _ := FromGo(map[string]any{"X": 3, "label": "p"})
+	var p point
+	if err := ToGo(hash, &p); err != nil || p.X != 3 || p.Label != "p" {
+		t.Errorf("ToGo struct wrong, got=%+v (%v)", p, err)
+	}
+
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	var b *big.Int
+	if err := ToGo(&BigInt{Value: huge}, &b); err != nil || b.Cmp(huge) != 0 {
+		t.Errorf("ToGo *big.Int wrong, got=%v (%v)", b, err)
+	}
+
+	var i64 int64
+	if err := ToGo(&BigInt{Value: huge}, &i64); err == nil {
+		t.Errorf("expected overflow error for BIGINT into int64")
+	}
+
+	var small int8
+	if err := ToGo(&Integer{Value: 300}, &small); err == nil {
+		t.Errorf("expected overflow error")
+	}
+
+	var s string
+	if err := ToGo(&Integer{Value: 1}, &s); err == nil {
+		t.Errorf("expected type error")
+	}
+
+	cyclic := &Array{Elems: []Object{&Integer{Value: 1}}}
+	cyclic.Elems = append(cyclic.Elems, cyclic)
+	if err := ToGo(cyclic, &anything); err == nil {
+		t.Errorf("expected error for cyclic array")
+	}
+}
+
+func TestWrapFunc(t *testing.T) {
+	ctx := InitContext()
+
+	sum, err := WrapFunc(func(nums ...int) int {
+		total := 0
+		for _, n := range nums {
+			total += n
+		}
+		return total
+	})
+	if err != nil {
+		t.Fatalf("WrapFunc returned error: %s", err)
+	}
+
+	res := sum.Fn(ctx, &Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3})
+	if res.Inspect() != "6" {
+		t.Errorf("wrong result, got=%s", res.Inspect())
+	}
+
+	div, _ := WrapFunc(func(a, b int) (int, int, error) {
+		if b == 0 {
+			return 0, 0, errors.New("division by zero")
+		}
+		return a / b, a % b, nil
+	})
+
+	res = div.Fn(ctx, &Integer{Value: 7}, &Integer{Value: 2})
+	if res.Inspect() != "[3, 1]" {
+		t.Errorf("wrong result, got=%s", res.Inspect())
+	}
+
+	res = div.Fn(ctx, &Integer{Value: 7}, &Integer{Value: 0})
+	if errObj, ok := res.(*Error); !ok || errObj.Message != "division by zero" {
+		t.Errorf("expected error object, got=%T (%+v)", res, res)
+	}
+
+	res = div.Fn(ctx, &Integer{Value: 7})
+	if errObj, ok := res.(*Error); !ok || errObj.Message != "wrong number of arguments, got=1, want=2" {
+		t.Errorf("expected argument error, got=%T (%+v)", res, res)
+	}
+
+	res = div.Fn(ctx, &String{Value: "7"}, &Integer{Value: 1})
+	if errObj, ok := res.(*Error); !ok || errObj.Message != "argument 1: cannot convert STRING to int" {
+		t.Errorf("expected conversion error, got=%T (%+v)", res, res)
+	}
+
+	deadline, _ := WrapFunc(func(ctx context.Context, name string) string {
+		if ctx.Err() != nil {
+			return "cancelled " + name
+		}
+		return "running " + name
+	})
+
+	res = deadline.Fn(ctx, &String{Value: "job"})
+	if res.Inspect() != "running job" {
+		t.Errorf("wrong result, got=%s", res.Inspect())
+	}
+
+	boom, _ := WrapFunc(func(xs []int, i int) int { return xs[i] })
+	res = boom.Fn(ctx, &Array{}, &Integer{Value: 1})
+	if errObj, ok := res.(*Error); !ok || !strings.HasPrefix(errObj.Message, "panic in wrapped func: ") {
+		t.Errorf("expected recovered panic, got=%T (%+v)", res, res)
+	}
+
+	if _, err := WrapFunc(42); err == nil {
+		t.Errorf("expected error wrapping non func")
+	}
+}
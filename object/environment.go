@@ -1,25 +1,66 @@
 package object
 
+import "sync"
+
+// Environment is safe for concurrent use by tasks. Get and Set on one
+// binding are atomic, but nothing makes a read followed by a write atomic:
+// tasks sharing a closure env see each other's `let` and the last write
+// wins. coordinate tasks with channels rather than shared bindings.
 type Environment struct {
+	mu    sync.RWMutex
 	store map[string]Object
 	outer *Environment
+	ctx   *Context
+
+	// set on the env of a running generator body, `yield` hands values to it
+	yield func(Object) bool
 }
 
 func InitEnv() *Environment {
+	return InitContextEnv(InitContext())
+}
+
+func InitContextEnv(ctx *Context) *Environment {
 	return &Environment{
 		store: make(map[string]Object),
 		outer: nil,
+		ctx:   ctx,
 	}
 }
 
 func InitEnclosedEnv(outer *Environment) *Environment {
-	env := InitEnv()
+	env := InitContextEnv(outer.ctx)
 	env.outer = outer
 	return env
 }
 
+func (e *Environment) Context() *Context {
+	return e.ctx
+}
+
+// SetContext is used on function call so the callee runs with the caller's context
+func (e *Environment) SetContext(ctx *Context) {
+	e.ctx = ctx
+}
+
+func (e *Environment) SetYield(yield func(Object) bool) {
+	e.yield = yield
+}
+
+// Yield returns the yield func of the innermost generator body around e
+func (e *Environment) Yield() (func(Object) bool, bool) {
+	if e.yield == nil && e.outer != nil {
+		return e.outer.Yield()
+	}
+
+	return e.yield, e.yield != nil
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
+
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
@@ -27,6 +68,9 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
 	e.store[name] = val
+	e.mu.Unlock()
+
 	return val
 }
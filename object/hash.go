@@ -0,0 +1,263 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+)
+
+// Hash keeps its pairs in insertion order so printing and iteration are
+// deterministic, index maps a HashKey to the positions of the pairs using it.
+// pairs whose keys collide on the 64 bit value are told apart with Equals so
+// a collision never overwrites a pair. a deleted pair leaves a hole (a nil
+// Key) in pairs so no position moves, the holes are dropped once they make
+// up half of pairs.
+type Hash struct {
+	pairs   []HashPair
+	index   map[HashKey][]int
+	deleted int
+}
+
+func InitHash() *Hash {
+	return &Hash{index: make(map[HashKey][]int)}
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return inspect(h, map[Object]bool{}) }
+
+// Equals ignores order, two hashes holding the same pairs are equal
+func (h *Hash) Equals(other Object) bool {
+	return equals(h, other, map[[2]Object]bool{})
+}
+
+// Get returns the pair stored under key, ok is false when key is missing or not hashable
+func (h *Hash) Get(key Object) (HashPair, bool) {
+	i, ok := h.find(key)
+	if !ok {
+		return HashPair{}, false
+	}
+
+	return h.pairs[i], true
+}
+
+// Set stores val under key, a new key goes to the end while an existing one
+// keeps its position. it report false when key is not hashable. array keys
+// are copied so mutating the array later does not corrupt the hash.
+func (h *Hash) Set(key, val Object) bool {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return false
+	}
+
+	if i, ok := h.find(key); ok {
+		h.pairs[i].Val = val
+		return true
+	}
+
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+
+	h.index[hashKey] = append(h.index[hashKey], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: freezeKey(key), Val: val})
+	return true
+}
+
+// Delete removes the pair stored under key, the pairs after it keep their order
+func (h *Hash) Delete(key Object) (HashPair, bool) {
+	i, ok := h.find(key)
+	if !ok {
+		return HashPair{}, false
+	}
+
+	pair := h.pairs[i]
+	hashKey, _ := HashKeyOf(pair.Key)
+
+	positions := slices.DeleteFunc(h.index[hashKey], func(j int) bool { return j == i })
+	if len(positions) == 0 {
+		delete(h.index, hashKey)
+	} else {
+		h.index[hashKey] = positions
+	}
+
+	h.pairs[i] = HashPair{}
+	h.deleted++
+	if h.deleted*2 >= len(h.pairs) {
+		h.compact()
+	}
+
+	return pair, true
+}
+
+// compact drops the holes left by Delete and rebuilds the index
+func (h *Hash) compact() {
+	h.pairs = slices.DeleteFunc(h.pairs, func(p HashPair) bool { return p.Key == nil })
+	h.deleted = 0
+
+	h.index = make(map[HashKey][]int, len(h.pairs))
+	for i, p := range h.pairs {
+		hashKey, _ := HashKeyOf(p.Key)
+		h.index[hashKey] = append(h.index[hashKey], i)
+	}
+}
+
+func (h *Hash) Len() int {
+	return len(h.pairs) - h.deleted
+}
+
+// Items returns every pair in insertion order, the slice must not be modified
+func (h *Hash) Items() []HashPair {
+	if h.deleted == 0 {
+		return h.pairs
+	}
+
+	items := make([]HashPair, 0, h.Len())
+	for _, pair := range h.pairs {
+		if pair.Key != nil {
+			items = append(items, pair)
+		}
+	}
+
+	return items
+}
+
+func (h *Hash) find(key Object) (int, bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return 0, false
+	}
+
+	for _, i := range h.index[hashKey] {
+		if Equals(h.pairs[i].Key, key) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func freezeKey(key Object) Object {
+	arr, ok := key.(*Array)
+	if !ok {
+		return key
+	}
+
+	elems := make([]Object, len(arr.Elems))
+	for i, elem := range arr.Elems {
+		elems[i] = freezeKey(elem)
+	}
+
+	return &Array{Elems: elems}
+}
+
+type Hashable interface {
+	HashKey() HashKey
+}
+
+type HashPair struct {
+	Key Object
+	Val Object
+}
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// HashKeyOf is the checked way to get a key, unlike a type assertion on
+// Hashable it also rejects arrays holding something unhashable or themselves
+func HashKeyOf(obj Object) (HashKey, bool) {
+	if !hashable(obj, nil) {
+		return HashKey{}, false
+	}
+
+	return obj.(Hashable).HashKey(), true
+}
+
+// hashable reports whether obj can be a key, path holds the arrays
+// containing obj so a cyclic array is rejected
+func hashable(obj Object, path []*Array) bool {
+	arr, ok := obj.(*Array)
+	if !ok {
+		_, ok := obj.(Hashable)
+		return ok
+	}
+
+	if slices.Contains(path, arr) {
+		return false
+	}
+
+	path = append(path, arr)
+	for _, elem := range arr.Elems {
+		if !hashable(elem, path) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{
+		Type:  i.Type(),
+		Value: uint64(i.Value),
+	}
+}
+
+func (b *Bool) HashKey() HashKey {
+	var val uint64
+
+	if b.Value {
+		val = 1
+	} else {
+		val = 0
+	}
+
+	return HashKey{
+		Type:  b.Type(),
+		Value: val,
+	}
+}
+
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte{byte(b.Value.Sign() + 1)})
+	h.Write(b.Value.Bytes())
+
+	return HashKey{
+		Type:  b.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{
+		Type:  s.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// HashKey combines the element keys in order, arrays must not be mutated
+// while they are used as a key. unhashable elements only add their type,
+// use HashKeyOf to reject them.
+func (a *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	var buf [8]byte
+
+	for _, elem := range a.Elems {
+		h.Write([]byte(elem.Type()))
+
+		if hashable, ok := elem.(Hashable); ok {
+			binary.LittleEndian.PutUint64(buf[:], hashable.HashKey().Value)
+			h.Write(buf[:])
+		}
+	}
+
+	return HashKey{
+		Type:  a.Type(),
+		Value: h.Sum64(),
+	}
+}
@@ -0,0 +1,161 @@
+package object
+
+import (
+	"fmt"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+const (
+	ITER_OBJ  = "ITERATOR"
+	GEN_OBJ   = "GENERATOR"
+	RANGE_OBJ = "RANGE"
+)
+
+// Iterator is the lazy iteration protocol. Next returns false once
+// exhausted, an ERROR value ends the iteration and should be propagated by
+// whoever consumes it.
+type Iterator interface {
+	Object
+	Next() (Object, bool)
+}
+
+// Iterable is anything that can hand out a fresh Iterator over itself
+type Iterable interface {
+	Iter() Iterator
+}
+
+// Iter is an Iterator backed by a Go func
+type Iter struct {
+	next func() (Object, bool)
+}
+
+func InitIter(next func() (Object, bool)) *Iter {
+	return &Iter{next: next}
+}
+
+func (it *Iter) Type() ObjectType     { return ITER_OBJ }
+func (it *Iter) Inspect() string      { return "iterator" }
+func (it *Iter) Next() (Object, bool) { return it.next() }
+func (it *Iter) Iter() Iterator       { return it }
+
+// Generator runs a function body as a coroutine, each `yield` hands one
+// value to Next and suspends the body until Next is called again. a
+// generator dropped before it is exhausted is stopped once collected.
+type Generator struct {
+	mu   sync.Mutex // tasks may share a generator, the coroutine must not
+	next func() (Object, bool)
+	stop func()
+}
+
+func InitGenerator(seq iter.Seq[Object]) *Generator {
+	next, stop := iter.Pull(seq)
+
+	g := &Generator{next: next, stop: stop}
+	runtime.AddCleanup(g, func(stop func()) { stop() }, stop)
+
+	return g
+}
+
+func (g *Generator) Type() ObjectType { return GEN_OBJ }
+func (g *Generator) Inspect() string  { return "generator" }
+func (g *Generator) Next() (Object, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.next()
+}
+func (g *Generator) Iter() Iterator { return g }
+
+// Stop ends the generator early, the suspended body unwinds with an error
+func (g *Generator) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.stop()
+}
+
+// Range is the lazy sequence Start, Start+Step, ... up to but excluding Stop
+type Range struct {
+	Start int64
+	Stop  int64
+	Step  int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.Stop, r.Step)
+}
+func (r *Range) Equals(other Object) bool {
+	o, ok := other.(*Range)
+	return ok && *r == *o
+}
+func (r *Range) Iter() Iterator {
+	i := r.Start
+	done := (r.Step > 0 && i >= r.Stop) || (r.Step < 0 && i <= r.Stop)
+	return InitIter(func() (Object, bool) {
+		if done {
+			return nil, false
+		}
+
+		val := &Integer{Value: i}
+
+		// distances in uint64 so a step past Stop ends instead of wrapping
+		left, step := uint64(r.Stop)-uint64(i), uint64(r.Step)
+		if r.Step < 0 {
+			left, step = uint64(i)-uint64(r.Stop), -step
+		}
+
+		if left <= step {
+			done = true
+		} else {
+			i += r.Step
+		}
+		return val, true
+	})
+}
+
+func (a *Array) Iter() Iterator {
+	i := 0
+	return InitIter(func() (Object, bool) {
+		if i >= len(a.Elems) {
+			return nil, false
+		}
+
+		i++
+		return a.Elems[i-1], true
+	})
+}
+
+// Iter over a hash yields [key, value] arrays in insertion order
+func (h *Hash) Iter() Iterator {
+	i := 0
+	return InitIter(func() (Object, bool) {
+		for i < len(h.pairs) && h.pairs[i].Key == nil {
+			i++
+		}
+
+		if i >= len(h.pairs) {
+			return nil, false
+		}
+
+		pair := h.pairs[i]
+		i++
+		return &Array{Elems: []Object{pair.Key, pair.Val}}, true
+	})
+}
+
+// Iter over a string yields one rune strings
+func (s *String) Iter() Iterator {
+	runes := []rune(s.Value)
+	i := 0
+	return InitIter(func() (Object, bool) {
+		if i >= len(runes) {
+			return nil, false
+		}
+
+		i++
+		return &String{Value: string(runes[i-1])}, true
+	})
+}
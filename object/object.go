@@ -3,7 +3,8 @@ package object
 import (
 	"bytes"
 	"fmt"
-	"hash/fnv"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/Aergiaaa/simplescript/ast"
@@ -17,6 +18,8 @@ const (
 	FUNC_OBJ    = "FUNCTION"
 	RET_VAL_OBJ = "RETURN_VALUE"
 	INTEGER_OBJ = "INTEGER"
+	BIGINT_OBJ  = "BIGINT"
+	FLOAT_OBJ   = "FLOAT"
 	BOOL_OBJ    = "BOOL"
 	STRING_OBJ  = "STRING"
 	ARR_OBJ     = "ARRAY"
@@ -29,7 +32,33 @@ type Object interface {
 	Inspect() string
 }
 
-type BuiltinFn func(args ...Object) Object
+// Equaler is implemented by objects compared by content instead of identity
+type Equaler interface {
+	Equals(other Object) bool
+}
+
+// Equals report whether a and b are structurally equal, objects that are not
+// Equaler (functions, builtins) are only equal to themselves
+func Equals(a, b Object) bool {
+	if a == b {
+		return true
+	}
+
+	if eq, ok := a.(Equaler); ok {
+		return eq.Equals(b)
+	}
+
+	return false
+}
+
+// there is only ever one null, true and false so they can be compared by pointer
+var (
+	NULL  = &Null{}
+	TRUE  = &Bool{Value: true}
+	FALSE = &Bool{Value: false}
+)
+
+type BuiltinFn func(ctx *Context, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFn
@@ -42,9 +71,17 @@ type Null struct{}
 
 func (n *Null) Inspect() string  { return "null" }
 func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Equals(other Object) bool {
+	_, ok := other.(*Null)
+	return ok
+}
 
 type Error struct {
 	Message string
+
+	// set by `exit`, it unwinds the script like any error but is not a failure
+	Exit bool
+	Code int
 }
 
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
@@ -54,6 +91,7 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Generator  bool // body uses yield, calling it returns a GENERATOR
 }
 
 func (f *Function) Inspect() string {
@@ -84,105 +122,205 @@ type ReturnValue struct {
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 func (rv *ReturnValue) Type() ObjectType { return RET_VAL_OBJ }
 
-type Hash struct {
-	Pairs map[HashKey]HashPair
+// Array and Hash are reference values: `let b = a` and passing a to a
+// function share one collection, nothing is copied. the builtins ending in
+// ! (append!, set!, ...) mutate in place and every alias sees the change,
+// the others (push, killHead, slices) leave their input alone and return a
+// new collection. collections are not synchronized, tasks must not mutate
+// one they share. a collection may end up holding itself, printing shows
+// the cycle as [...] or {...} while json_stringify, flatten and ToGo
+// reject it.
+type Array struct {
+	Elems []Object
 }
 
-func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
+func (a *Array) Type() ObjectType { return ARR_OBJ }
+func (a *Array) Inspect() string  { return inspect(a, map[Object]bool{}) }
+
+func (a *Array) Equals(other Object) bool {
+	return equals(a, other, map[[2]Object]bool{})
+}
+
+// inspect prints obj, an array or hash met again inside itself prints as
+// [...] or {...}. seen holds the collections being printed
+func inspect(obj Object, seen map[Object]bool) string {
 	var output bytes.Buffer
 
-	var pairs []string
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Val.Inspect()))
+	switch obj := obj.(type) {
+	case *Array:
+		if seen[obj] {
+			return "[...]"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		var elems []string
+		for _, e := range obj.Elems {
+			elems = append(elems, inspect(e, seen))
+		}
+
+		output.WriteString("[")
+		output.WriteString(strings.Join(elems, ", "))
+		output.WriteString("]")
+	case *Hash:
+		if seen[obj] {
+			return "{...}"
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		var pairs []string
+		for _, pair := range obj.Items() {
+			pairs = append(pairs, inspect(pair.Key, seen)+": "+inspect(pair.Val, seen))
+		}
+
+		output.WriteString("{")
+		output.WriteString(strings.Join(pairs, ", "))
+		output.WriteString("}")
+	default:
+		return obj.Inspect()
 	}
 
-	output.WriteString("{")
-	output.WriteString(strings.Join(pairs, ", "))
-	output.WriteString("}")
-
 	return output.String()
 }
 
-type Hashable interface {
-	HashKey() HashKey
-}
+// equals compares arrays and hashes element by element. seen holds the
+// pairs of collections already being compared, meeting a pair again inside
+// itself means the cycles match so far
+func equals(a, b Object, seen map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
 
-type HashPair struct {
-	Key Object
-	Val Object
+	switch a := a.(type) {
+	case *Array:
+		o, ok := b.(*Array)
+		if !ok || len(a.Elems) != len(o.Elems) {
+			return false
+		}
+
+		if seen[[2]Object{a, o}] {
+			return true
+		}
+		seen[[2]Object{a, o}] = true
+
+		for i, elem := range a.Elems {
+			if !equals(elem, o.Elems[i], seen) {
+				return false
+			}
+		}
+
+		return true
+	case *Hash:
+		o, ok := b.(*Hash)
+		if !ok || a.Len() != o.Len() {
+			return false
+		}
+
+		if seen[[2]Object{a, o}] {
+			return true
+		}
+		seen[[2]Object{a, o}] = true
+
+		for _, pair := range a.Items() {
+			otherPair, ok := o.Get(pair.Key)
+			if !ok || !equals(pair.Val, otherPair.Val, seen) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return Equals(a, b)
+	}
 }
 
-type HashKey struct {
-	Type  ObjectType
-	Value uint64
+type String struct {
+	Value string
 }
 
-func (i *Integer) HashKey() HashKey {
-	return HashKey{
-		Type:  i.Type(),
-		Value: uint64(i.Value),
-	}
+func (s *String) Inspect() string  { return s.Value }
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Equals(other Object) bool {
+	o, ok := other.(*String)
+	return ok && s.Value == o.Value
 }
 
-func (b *Bool) HashKey() HashKey {
-	var val uint64
-
-	if b.Value {
-		val = 1
-	} else {
-		val = 0
-	}
-
-	return HashKey{
-		Type:  b.Type(),
-		Value: val,
-	}
+type Integer struct {
+	Value int64
 }
 
-func (s *String) HashKey() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(s.Value))
-
-	return HashKey{
-		Type:  s.Type(),
-		Value: h.Sum64(),
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Equals(other Object) bool {
+	switch o := other.(type) {
+	case *Integer:
+		return i.Value == o.Value
+	case *BigInt:
+		return o.Value.IsInt64() && o.Value.Int64() == i.Value
+	case *Float:
+		return float64(i.Value) == o.Value
+	default:
+		return false
 	}
 }
 
-type Array struct {
-	Elems []Object
+// BigInt holds integers outside the int64 range. arithmetic promotes
+// INTEGER to BIGINT on overflow and IntegerFromBig demotes results that
+// fit again, so the two never hold the same number.
+type BigInt struct {
+	Value *big.Int
 }
 
-func (a *Array) Type() ObjectType { return ARR_OBJ }
-func (a *Array) Inspect() string {
-	var output bytes.Buffer
-
-	var elems []string
-	for _, e := range a.Elems {
-		elems = append(elems, e.Inspect())
+// IntegerFromBig returns an INTEGER when v fits in int64, a BIGINT otherwise
+func IntegerFromBig(v *big.Int) Object {
+	if v.IsInt64() {
+		return &Integer{Value: v.Int64()}
 	}
 
-	output.WriteString("[")
-	output.WriteString(strings.Join(elems, ", "))
-	output.WriteString("]")
-
-	return output.String()
+	return &BigInt{Value: v}
 }
 
-type String struct {
-	Value string
+func (b *BigInt) Inspect() string  { return b.Value.String() }
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (b *BigInt) Equals(other Object) bool {
+	switch o := other.(type) {
+	case *BigInt:
+		return b.Value.Cmp(o.Value) == 0
+	case *Integer:
+		return o.Equals(b)
+	case *Float:
+		f, _ := new(big.Float).SetInt(b.Value).Float64()
+		return f == o.Value
+	default:
+		return false
+	}
 }
 
-func (s *String) Inspect() string  { return s.Value }
-func (s *String) Type() ObjectType { return STRING_OBJ }
-
-type Integer struct {
-	Value int64
+type Float struct {
+	Value float64
 }
 
-func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
-func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Equals(other Object) bool {
+	switch o := other.(type) {
+	case *Float:
+		return f.Value == o.Value
+	case *Integer:
+		return f.Value == float64(o.Value)
+	case *BigInt:
+		return o.Equals(f)
+	default:
+		return false
+	}
+}
 
 type Bool struct {
 	Value bool
@@ -190,3 +328,7 @@ type Bool struct {
 
 func (b *Bool) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 func (b *Bool) Type() ObjectType { return BOOL_OBJ }
+func (b *Bool) Equals(other Object) bool {
+	o, ok := other.(*Bool)
+	return ok && b.Value == o.Value
+}
@@ -1,6 +1,9 @@
 package object
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +20,225 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestArrayHashKey(t *testing.T) {
+	arr := func(elems ...Object) *Array { return &Array{Elems: elems} }
+
+	a1 := arr(&Integer{Value: 2024}, &String{Value: "jan"})
+	a2 := arr(&Integer{Value: 2024}, &String{Value: "jan"})
+	swapped := arr(&String{Value: "jan"}, &Integer{Value: 2024})
+	nested := arr(arr(&Integer{Value: 2024}), &String{Value: "jan"})
+
+	if a1.HashKey() != a2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+	if a1.HashKey() == swapped.HashKey() {
+		t.Errorf("arrays with different order have same hash keys")
+	}
+	if a1.HashKey() == nested.HashKey() {
+		t.Errorf("arrays with different nesting have same hash keys")
+	}
+
+	if _, ok := HashKeyOf(arr(&Integer{Value: 1}, &Builtin{})); ok {
+		t.Errorf("array holding a builtin should not be hashable")
+	}
+}
+
+// collider always hash to the same key to force a collision
+type collider struct {
+	name string
+}
+
+func (c *collider) Type() ObjectType { return "COLLIDER" }
+func (c *collider) Inspect() string  { return c.name }
+func (c *collider) HashKey() HashKey { return HashKey{Type: c.Type(), Value: 1} }
+func (c *collider) Equals(other Object) bool {
+	o, ok := other.(*collider)
+	return ok && o.name == c.name
+}
+
+func TestHashCollisions(t *testing.T) {
+	hash := InitHash()
+	hash.Set(&collider{"a"}, &Integer{Value: 1})
+	hash.Set(&collider{"b"}, &Integer{Value: 2})
+	hash.Set(&collider{"a"}, &Integer{Value: 3})
+
+	if hash.Len() != 2 {
+		t.Fatalf("hash has wrong num of pairs. got=%d", hash.Len())
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"a", "3"},
+		{"b", "2"},
+	}
+
+	for _, tt := range tests {
+		pair, ok := hash.Get(&collider{tt.key})
+		if !ok {
+			t.Errorf("no pair for key %q", tt.key)
+			continue
+		}
+
+		if pair.Val.Inspect() != tt.expected {
+			t.Errorf("wrong value for key %q. got=%s, want=%s", tt.key, pair.Val.Inspect(), tt.expected)
+		}
+	}
+
+	if _, ok := hash.Get(&collider{"c"}); ok {
+		t.Errorf("colliding missing key was found")
+	}
+}
+
+func TestHashDelete(t *testing.T) {
+	hash := InitHash()
+	hash.Set(&collider{"a"}, &Integer{Value: 1})
+	hash.Set(&collider{"b"}, &Integer{Value: 2})
+	hash.Set(&String{Value: "c"}, &Integer{Value: 3})
+
+	pair, ok := hash.Delete(&collider{"a"})
+	if !ok || pair.Val.Inspect() != "1" {
+		t.Fatalf("wrong deleted pair. got=%v, %t", pair, ok)
+	}
+
+	if _, ok := hash.Delete(&collider{"a"}); ok {
+		t.Errorf("deleted key deleted twice")
+	}
+
+	if hash.Inspect() != "{b: 2, c: 3}" {
+		t.Errorf("wrong hash after delete. got=%s", hash.Inspect())
+	}
+
+	if pair, ok := hash.Get(&String{Value: "c"}); !ok || pair.Val.Inspect() != "3" {
+		t.Errorf("pair after deleted one not found")
+	}
+
+	many := InitHash()
+	for i := range 10 {
+		many.Set(&Integer{Value: int64(i)}, &Integer{Value: int64(i)})
+	}
+	for i := range 10 {
+		if i%3 != 0 {
+			many.Delete(&Integer{Value: int64(i)})
+		}
+	}
+	many.Set(&Integer{Value: 1}, &Integer{Value: 1})
+
+	if many.Inspect() != "{0: 0, 3: 3, 6: 6, 9: 9, 1: 1}" || many.Len() != 5 {
+		t.Errorf("wrong hash after deletes. got=%s (len %d)", many.Inspect(), many.Len())
+	}
+
+	for _, key := range []int64{0, 3, 6, 9, 1} {
+		if pair, ok := many.Get(&Integer{Value: key}); !ok || pair.Val.Inspect() != pair.Key.Inspect() {
+			t.Errorf("key %d lost after deletes", key)
+		}
+	}
+
+	var keys []string
+	for it := many.Iter(); ; {
+		pair, ok := it.Next()
+		if !ok {
+			break
+		}
+		keys = append(keys, pair.(*Array).Elems[0].Inspect())
+	}
+	if strings.Join(keys, " ") != "0 3 6 9 1" {
+		t.Errorf("wrong iteration after deletes. got=%v", keys)
+	}
+}
+
+func TestEquals(t *testing.T) {
+	arr := func(elems ...Object) *Array { return &Array{Elems: elems} }
+	one := &Integer{Value: 1}
+
+	tests := []struct {
+		left, right Object
+		expected    bool
+	}{
+		{one, &Integer{Value: 1}, true},
+		{one, &Float{Value: 1}, true},
+		{one, &String{Value: "1"}, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&Null{}, NULL, true},
+		{arr(one, arr(TRUE)), arr(&Integer{Value: 1}, arr(&Bool{Value: true})), true},
+		{arr(one), arr(one, one), false},
+		{&Builtin{}, &Builtin{}, false},
+	}
+
+	for i, tt := range tests {
+		if Equals(tt.left, tt.right) != tt.expected {
+			t.Errorf("tests[%d] - Equals(%s, %s) wrong, want=%t",
+				i, tt.left.Inspect(), tt.right.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestGeneratorStop(t *testing.T) {
+	unwound := false
+	gen := InitGenerator(func(yield func(Object) bool) {
+		for i := int64(0); ; i++ {
+			if !yield(&Integer{Value: i}) {
+				unwound = true
+				return
+			}
+		}
+	})
+
+	for i := int64(0); i < 3; i++ {
+		val, ok := gen.Next()
+		if !ok || val.(*Integer).Value != i {
+			t.Fatalf("wrong value at %d. got=%v", i, val)
+		}
+	}
+
+	gen.Stop()
+	if !unwound {
+		t.Errorf("generator body did not unwind on Stop")
+	}
+
+	if _, ok := gen.Next(); ok {
+		t.Errorf("stopped generator still produce values")
+	}
+}
+
+func TestCompileRegexCache(t *testing.T) {
+	a, err := CompileRegex(`\d+`)
+	if err != nil {
+		t.Fatalf("compile failed: %s", err)
+	}
+
+	b, _ := CompileRegex(`\d+`)
+	if a != b {
+		t.Errorf("pattern compiled twice")
+	}
+
+	if _, err := CompileRegex("("); err == nil {
+		t.Errorf("invalid pattern compiled")
+	}
+}
+
+// funcReader is not comparable, reading from it must not compare readers
+type funcReader func([]byte) (int, error)
+
+func (f funcReader) Read(p []byte) (int, error) { return f(p) }
+
+func TestReadLineStdin(t *testing.T) {
+	ctx := InitContext()
+	ctx.Stdin = funcReader(strings.NewReader("a\nb\n").Read)
+	fork := ctx.Fork()
+
+	if line, ok, err := ctx.ReadLine(); line != "a" || !ok || err != nil {
+		t.Fatalf("wrong first line. got=%q, %t, %v", line, ok, err)
+	}
+
+	if line, _, _ := fork.ReadLine(); line != "b" {
+		t.Errorf("fork did not share the input. got=%q", line)
+	}
+
+	ctx.SetStdin(strings.NewReader("c\n"))
+	if line, _, _ := fork.ReadLine(); line != "c" {
+		t.Errorf("fork did not see the new stdin. got=%q", line)
+	}
+}
@@ -0,0 +1,52 @@
+package object
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+const REGEX_OBJ = "REGEX"
+
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (r *Regex) Type() ObjectType { return REGEX_OBJ }
+func (r *Regex) Inspect() string  { return "re(" + strconv.Quote(r.Value.String()) + ")" }
+func (r *Regex) Equals(other Object) bool {
+	o, ok := other.(*Regex)
+	return ok && r.Value.String() == o.Value.String()
+}
+
+// compiled patterns are kept so a pattern used in a loop is parsed once,
+// the cache is dropped when it grows past regexCacheSize
+const regexCacheSize = 256
+
+var regexCache = struct {
+	sync.Mutex
+	m map[string]*Regex
+}{m: make(map[string]*Regex)}
+
+// CompileRegex returns the Regex of pattern, from the cache when it was seen before
+func CompileRegex(pattern string) (*Regex, error) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if r, ok := regexCache.m[pattern]; ok {
+		return r, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regexCache.m) >= regexCacheSize {
+		clear(regexCache.m)
+	}
+
+	r := &Regex{Value: compiled}
+	regexCache.m[pattern] = r
+	return r, nil
+}
@@ -0,0 +1,130 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	TASK_OBJ = "TASK"
+	CHAN_OBJ = "CHANNEL"
+)
+
+var ErrClosedChannel = errors.New("send on closed channel")
+
+// Task is the handle of a function running on its own goroutine
+type Task struct {
+	done   chan struct{}
+	result Object
+}
+
+// InitTask starts run on a new goroutine
+func InitTask(run func() Object) *Task {
+	t := &Task{done: make(chan struct{})}
+
+	go func() {
+		defer close(t.done)
+		t.result = run()
+	}()
+
+	return t
+}
+
+func (t *Task) Type() ObjectType { return TASK_OBJ }
+func (t *Task) Inspect() string  { return "task" }
+
+// Await blocks until the task finished or ctx is done
+func (t *Task) Await(ctx context.Context) (Object, error) {
+	select {
+	case <-t.done:
+		return t.result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Channel passes values between tasks. closing it never panics a sender,
+// Send reports ErrClosedChannel instead, and receivers drain what is
+// buffered before seeing the close.
+type Channel struct {
+	ch        chan Object
+	closed    chan struct{}
+	closeOnce sync.Once
+	cap       int
+}
+
+// MaxChannelSize is the largest buffer a channel may have
+const MaxChannelSize = 1 << 20
+
+// InitChannel makes a channel buffering size values, from 0 to MaxChannelSize
+func InitChannel(size int) *Channel {
+	return &Channel{
+		ch:     make(chan Object, size),
+		closed: make(chan struct{}),
+		cap:    size,
+	}
+}
+
+func (c *Channel) Type() ObjectType { return CHAN_OBJ }
+func (c *Channel) Inspect() string  { return fmt.Sprintf("channel(%d)", c.cap) }
+
+func (c *Channel) Send(ctx context.Context, val Object) error {
+	select {
+	case <-c.closed:
+		return ErrClosedChannel
+	default:
+	}
+
+	select {
+	case c.ch <- val:
+		return nil
+	case <-c.closed:
+		return ErrClosedChannel
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Recv returns the next value, ok is false once the channel is closed and drained
+func (c *Channel) Recv(ctx context.Context) (val Object, ok bool, err error) {
+	select {
+	case val := <-c.ch:
+		return val, true, nil
+	case <-c.closed:
+		val, ok := c.TryRecv()
+		return val, ok, nil
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// TryRecv returns a buffered value without blocking
+func (c *Channel) TryRecv() (Object, bool) {
+	select {
+	case val := <-c.ch:
+		return val, true
+	default:
+		return nil, false
+	}
+}
+
+// Close is idempotent
+func (c *Channel) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// Chans exposes the value and close channels, for select
+func (c *Channel) Chans() (<-chan Object, <-chan struct{}) {
+	return c.ch, c.closed
+}
+
+// Iter receives until the channel is closed and drained. it cannot be
+// cancelled, the evaluator receives with the run's context instead
+func (c *Channel) Iter() Iterator {
+	return InitIter(func() (Object, bool) {
+		val, ok, _ := c.Recv(context.Background())
+		return val, ok
+	})
+}
@@ -0,0 +1,93 @@
+package object
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	TIME_OBJ     = "TIME"
+	DURATION_OBJ = "DURATION"
+)
+
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+func (t *Time) Equals(other Object) bool {
+	o, ok := other.(*Time)
+	return ok && t.Value.Equal(o.Value)
+}
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+func (d *Duration) Inspect() string  { return d.Value.String() }
+func (d *Duration) Equals(other Object) bool {
+	o, ok := other.(*Duration)
+	return ok && d.Value == o.Value
+}
+
+// Clock is where `now` and `sleep` get the time from, swap it for a
+// FrozenClock to make scripts using time deterministic
+type Clock interface {
+	Now() time.Time
+
+	// Sleep waits d or until ctx is done, whichever comes first
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var SystemClock Clock = systemClock{}
+
+// FrozenClock only moves when told to, Sleep advances it without blocking
+type FrozenClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func InitFrozenClock(now time.Time) *FrozenClock {
+	return &FrozenClock{now: now}
+}
+
+func (c *FrozenClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FrozenClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.Advance(d)
+	return nil
+}
+
+func (c *FrozenClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
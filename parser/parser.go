@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/Aergiaaa/simplescript/ast"
@@ -15,7 +16,7 @@ type Hierarchy int
 const (
 	_ Hierarchy = iota
 	LOWEST
-	EQUALS           //==
+	EQUALS           //== != in
 	LESSGREATEREQUAL // <= >=
 	LESSGREATER      // < >
 	SUM              //X+Y
@@ -28,6 +29,7 @@ const (
 var hierarchy = map[token.TokenType]Hierarchy{
 	token.EQ:       EQUALS,
 	token.NEQ:      EQUALS,
+	token.IN:       EQUALS,
 	token.LTE:      LESSGREATEREQUAL,
 	token.GTE:      LESSGREATEREQUAL,
 	token.LT:       LESSGREATER,
@@ -55,6 +57,13 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// function literals being parsed and whether the innermost one yields
+	funcDepth int
+	yielded   bool
+
+	// every function literal parsed inside the outermost one being parsed
+	nested []*ast.FunctionLiteral
 }
 
 func InitParser(l *lexer.Lexer) *Parser {
@@ -82,6 +91,8 @@ func InitParser(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IF, p.parseIFExpression)
 
 	p.registerPrefix(token.FUNC, p.parseFuncLiteral)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.SPAWN, p.parseSpawnExpression)
 
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 
@@ -93,6 +104,7 @@ func InitParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NEQ, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LTE, p.parseInfixExpression)
 	p.registerInfix(token.GTE, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
@@ -111,8 +123,9 @@ func InitParser(l *lexer.Lexer) *Parser {
 	return p
 }
 
+// Errors returns the lexer errors followed by the parser ones
 func (p *Parser) Errors() []string {
-	return p.errors
+	return slices.Concat(p.lexer.Errors(), p.errors)
 }
 
 func (p *Parser) peekError(t token.TokenType) {
@@ -262,6 +275,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		val := p.parseExpression(LOWEST)
 
 		hash.Pairs[key] = val
+		hash.Keys = append(hash.Keys, key)
 
 		if p.peekTokenIs(token.RBRACE) {
 			break
@@ -295,8 +309,17 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	}
 	p.nextToken()
 
+	if p.currTokenIs(token.COLON) {
+		return p.parseSliceExpression(idx.Token, left, nil)
+	}
+
 	idx.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(idx.Token, left, idx.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -305,6 +328,27 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return idx
 }
 
+// parseSliceExpression starts with the current token on the `:` of left[start:end]
+func (p *Parser) parseSliceExpression(tok token.Token, left, start ast.Expression) ast.Expression {
+	slice := &ast.SliceExpression{
+		Token: tok,
+		Left:  left,
+		Start: start,
+	}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		slice.End = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	p.nextToken()
+
+	return slice
+}
+
 func (p *Parser) parseFuncLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{
 		Token: p.currToken,
@@ -322,11 +366,69 @@ func (p *Parser) parseFuncLiteral() ast.Expression {
 	}
 	p.nextToken()
 
+	outerYielded := p.yielded
+	p.yielded = false
+	p.funcDepth++
+	first := len(p.nested)
+
 	lit.Body = p.parseBlockStatement()
+	lit.Generator = p.yielded
+
+	// inside a generator a yield in a nested function goes to the
+	// generator, so the nested function is not one itself
+	if lit.Generator {
+		for _, inner := range p.nested[first:] {
+			inner.Generator = false
+		}
+	}
+
+	p.funcDepth--
+	p.yielded = outerYielded
+
+	p.nested = append(p.nested, lit)
+	if p.funcDepth == 0 {
+		p.nested = p.nested[:0]
+	}
 
 	return lit
 }
 
+func (p *Parser) parseSpawnExpression() ast.Expression {
+	expr := &ast.SpawnExpression{
+		Token: p.currToken,
+	}
+	p.nextToken()
+
+	call, ok := p.parseExpression(PREFIX).(*ast.CallExpression)
+	if !ok {
+		p.errors = append(p.errors, "spawn expects a function call")
+		return nil
+	}
+	expr.Call = call
+
+	return expr
+}
+
+func (p *Parser) parseYieldExpression() ast.Expression {
+	expr := &ast.YieldExpression{
+		Token: p.currToken,
+	}
+
+	if p.funcDepth == 0 {
+		p.errors = append(p.errors, "yield outside function")
+	}
+	p.yielded = true
+
+	if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.RBRACE) {
+		return expr
+	}
+	p.nextToken()
+
+	expr.Value = p.parseExpression(LOWEST)
+
+	return expr
+}
+
 func (p *Parser) parseFuncParams() []*ast.Identifier {
 	var idens []*ast.Identifier
 
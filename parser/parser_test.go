@@ -108,6 +108,10 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 
 		testIntegerLiteral(t, value, expectedValue)
 	}
+
+	if hash.String() != "{one:1, two:2, three:3}" {
+		t.Errorf("hash.Keys not in source order. got=%q", hash.String())
+	}
 }
 
 func TestParsingIndexExpressions(t *testing.T) {
@@ -132,6 +136,106 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1:2]", "(xs[1:2])"},
+		{"xs[:n + 1]", "(xs[:(n + 1)])"},
+		{"xs[a * 2:]", "(xs[(a * 2):])"},
+		{"xs[:]", "(xs[:])"},
+		{`"héllo"[1:][0]`, "((héllo[1:])[0])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.InitLexer(tt.input)
+		p := InitParser(l)
+		program := p.Parse()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong slice parsing. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestYieldParsing(t *testing.T) {
+	input := `ft() { yield 1 + 2; let inner = ft() { 1 }; yield }`
+
+	p := InitParser(lexer.InitLexer(input))
+	program := p.Parse()
+	checkParserErrors(t, p)
+
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if !fn.Generator {
+		t.Errorf("function with yield not marked as generator")
+	}
+
+	if fn.Body.String() != "yield (1 + 2)let inner = ft() 1;yield" {
+		t.Errorf("wrong body. got=%q", fn.Body.String())
+	}
+
+	inner := fn.Body.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if inner.Generator {
+		t.Errorf("nested function without yield marked as generator")
+	}
+
+	input = `ft() { let loop = ft(i) { yield i; loop(i + 1) }; loop(0); yield -1 }; ft() { ft() { yield 1 } }`
+	p = InitParser(lexer.InitLexer(input))
+	program = p.Parse()
+	checkParserErrors(t, p)
+
+	outer := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	loop := outer.Body.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if !outer.Generator || loop.Generator {
+		t.Errorf("yield of a nested function does not go to the enclosing one")
+	}
+
+	wrapper := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	gen := wrapper.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if wrapper.Generator || !gen.Generator {
+		t.Errorf("function returning a generator marked as generator")
+	}
+
+	p = InitParser(lexer.InitLexer("yield 1"))
+	p.Parse()
+	if len(p.Errors()) != 1 || p.Errors()[0] != "yield outside function" {
+		t.Errorf("expected yield outside function error, got=%v", p.Errors())
+	}
+}
+
+func TestSpawnParsing(t *testing.T) {
+	p := InitParser(lexer.InitLexer("spawn add(1, 2 * 3)"))
+	program := p.Parse()
+	checkParserErrors(t, p)
+
+	spawn, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SpawnExpression)
+	if !ok {
+		t.Fatalf("expression is not *ast.SpawnExpression. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+
+	if spawn.String() != "spawn add(1, (2 * 3))" {
+		t.Errorf("wrong spawn. got=%q", spawn.String())
+	}
+
+	p = InitParser(lexer.InitLexer("spawn 1 + 2"))
+	p.Parse()
+	if len(p.Errors()) != 1 || p.Errors()[0] != "spawn expects a function call" {
+		t.Errorf("expected spawn expects a function call error, got=%v", p.Errors())
+	}
+}
+
+func TestLexerErrorsReported(t *testing.T) {
+	p := InitParser(lexer.InitLexer("\"bad \xff\""))
+	p.Parse()
+
+	if len(p.Errors()) != 1 || p.Errors()[0] != "invalid UTF-8 encoding at offset 5" {
+		t.Errorf("lexer error not reported. got=%v", p.Errors())
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -389,6 +493,10 @@ func TestOpHierarchyParsing(t *testing.T) {
 			"1 + (2 + 3) + 4",
 			"((1 + (2 + 3)) + 4)",
 		},
+		{
+			"a + 1 in b < c",
+			"((a + 1) in (b < c))",
+		},
 		{
 			"a + add(b * c) + d",
 			"((a + add((b * c))) + d)",
@@ -2,40 +2,52 @@ package repl
 
 import (
 	"bufio"
-	"fmt"
+	"errors"
 	"io"
+	"strings"
 
-	"github.com/Aergiaaa/simplescript/evaluator"
-	"github.com/Aergiaaa/simplescript/lexer"
-	"github.com/Aergiaaa/simplescript/object"
-	"github.com/Aergiaaa/simplescript/parser"
+	"github.com/Aergiaaa/simplescript/interpreter"
 )
 
 const PROMPT = ">>"
 
+// Start reads lines from in and evaluates them one at a time. in is also
+// the interpreter's Stdin through the same buffer, so read_line and friends
+// consume the lines typed after the one that called them.
 func Start(in io.Reader, out io.Writer) {
-	buffer := bufio.NewScanner(in)
-	env := object.InitEnv()
+	buffer := bufio.NewReader(in)
+	interp := interpreter.InitInterpreter(interpreter.Options{
+		Stdin:  buffer,
+		Stdout: out,
+		Stderr: out,
+	})
 
 	for {
-		fmt.Printf(PROMPT)
+		io.WriteString(out, PROMPT)
 
-		readed := buffer.Scan()
-		if !readed {
+		line, err := buffer.ReadString('\n')
+		if line == "" && err != nil {
 			return
 		}
 
-		line := buffer.Text()
-		l := lexer.InitLexer(line)
-		p := parser.InitParser(l)
+		evaled, err := interp.Run(strings.TrimRight(line, "\r\n"))
 
-		program := p.Parse()
-		if len(p.Errors()) != 0 {
-			printParseError(out, p.Errors())
+		var parseErr *interpreter.ParseError
+		if errors.As(err, &parseErr) {
+			printParseError(out, parseErr.Errors)
+			continue
+		}
+
+		var exitErr *interpreter.ExitError
+		if errors.As(err, &exitErr) {
+			return
+		}
+
+		if err != nil {
+			io.WriteString(out, "ERROR: "+err.Error()+"\n")
 			continue
 		}
 
-		evaled := evaluator.Eval(program, env)
 		if evaled != nil {
 			io.WriteString(out, evaled.Inspect())
 			io.WriteString(out, "\n")
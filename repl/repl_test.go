@@ -0,0 +1,52 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	in := strings.NewReader(`let x = 2
+puts(x * 21)
+x +
+5 + true
+`)
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">>" +
+		">>42\nnull\n" +
+		">>parser errors:\n\tno prefix parse function for EOF found\n" +
+		">>ERROR: type mismatch: INTEGER + BOOL\n" +
+		">>"
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestStartExit(t *testing.T) {
+	in := strings.NewReader("puts(1)\nexit(0)\nputs(2)\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">>1\nnull\n>>"
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestStartStdin(t *testing.T) {
+	in := strings.NewReader("let name = read_line()\nAda\nputs(\"hi \" + name)\n")
+	var out bytes.Buffer
+
+	Start(in, &out)
+
+	expected := ">>>>hi Ada\nnull\n>>"
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
@@ -26,6 +26,8 @@ const (
 	LTE = "<="
 	GTE = ">="
 
+	IN = "IN"
+
 	// delimiter
 	COMMA     = ","
 	SEMICOLON = ";"
@@ -48,6 +50,8 @@ const (
 	IF     = "IF"
 	ELSE   = "ELSE"
 	RETURN = "RETURN"
+	YIELD  = "YIELD"
+	SPAWN  = "SPAWN"
 )
 
 type TokenType string
@@ -57,7 +61,7 @@ type Token struct {
 	Literal string
 }
 
-func MakeToken(t TokenType, char byte) Token {
+func MakeToken(t TokenType, char rune) Token {
 	return Token{
 		Type:    t,
 		Literal: string(char),
@@ -80,4 +84,7 @@ var keyword = map[string]TokenType{
 	"if":     IF,
 	"else":   ELSE,
 	"return": RETURN,
+	"in":     IN,
+	"yield":  YIELD,
+	"spawn":  SPAWN,
 }